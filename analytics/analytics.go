@@ -0,0 +1,273 @@
+// Package analytics turns merkle traces into propagation reports: the timeline
+// of every trace, and which origins see transactions first across many traces.
+package analytics
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/merkle3/merkle-sdk-go/internal/stats"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+// when an origin first observed a transaction
+type TimelineEntry struct {
+	Origin string    `json:"origin"`
+	Time   time.Time `json:"time"`
+
+	// 1 for the first origin to observe the transaction
+	Rank int `json:"rank"`
+
+	// since the first observation of the transaction
+	SinceFirstSeen time.Duration `json:"since_first_seen_ns"`
+
+	// since the observation of the previous origin
+	SincePrevious time.Duration `json:"since_previous_ns"`
+}
+
+// the propagation of one transaction
+type TraceReport struct {
+	Hash        string               `json:"hash"`
+	ChainId     merkle.MerkleChainId `json:"chain_id"`
+	FirstSeenAt time.Time            `json:"first_seen_at"`
+
+	// one entry per origin, in the order they observed the transaction
+	Timeline []TimelineEntry `json:"timeline"`
+}
+
+// the ordered origin timeline of a trace, only the first
+// observation of every origin is kept
+func Timeline(trace *merkle.MerkleTrace) *TraceReport {
+	observations := make([]merkle.Observation, len(trace.Trace))
+	copy(observations, trace.Trace)
+
+	sort.SliceStable(observations, func(i, j int) bool {
+		return observations[i].Time.Before(observations[j].Time)
+	})
+
+	report := &TraceReport{
+		Hash:        trace.Hash,
+		ChainId:     trace.ChainId,
+		FirstSeenAt: trace.FirstSeenAt,
+		Timeline:    []TimelineEntry{},
+	}
+
+	seen := map[string]bool{}
+
+	for _, observation := range observations {
+		if seen[observation.Origin] {
+			continue
+		}
+
+		seen[observation.Origin] = true
+
+		entry := TimelineEntry{
+			Origin: observation.Origin,
+			Time:   observation.Time,
+			Rank:   len(report.Timeline) + 1,
+		}
+
+		if len(report.Timeline) > 0 {
+			entry.SinceFirstSeen = observation.Time.Sub(report.Timeline[0].Time)
+			entry.SincePrevious = observation.Time.Sub(report.Timeline[len(report.Timeline)-1].Time)
+		}
+
+		report.Timeline = append(report.Timeline, entry)
+	}
+
+	return report
+}
+
+func (r *TraceReport) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// one row per timeline entry, durations in milliseconds
+func (r *TraceReport) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"hash", "rank", "origin", "time", "since_first_seen_ms", "since_previous_ms"})
+
+	for _, entry := range r.Timeline {
+		writer.Write([]string{
+			r.Hash,
+			strconv.Itoa(entry.Rank),
+			entry.Origin,
+			entry.Time.UTC().Format(time.RFC3339Nano),
+			formatMs(entry.SinceFirstSeen),
+			formatMs(entry.SincePrevious),
+		})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// latency percentiles, from the first observation of a transaction
+type Percentiles struct {
+	P50 time.Duration `json:"p50_ns"`
+	P90 time.Duration `json:"p90_ns"`
+	P99 time.Duration `json:"p99_ns"`
+	Max time.Duration `json:"max_ns"`
+}
+
+// how an origin ranked across traces
+type OriginStats struct {
+	Origin string `json:"origin"`
+
+	// number of traces the origin observed
+	Traces int `json:"traces"`
+
+	// number of traces per rank, RankCounts[1] is the number of times it was first
+	RankCounts map[int]int `json:"rank_counts"`
+
+	MeanRank float64 `json:"mean_rank"`
+
+	// share of the traces it observed where it was first
+	LeadRate float64 `json:"lead_rate"`
+
+	// latency from the first observation of the transactions
+	Latency Percentiles `json:"latency"`
+}
+
+// the propagation across many traces
+type Report struct {
+	Traces int `json:"traces"`
+
+	// every origin, the most consistent leaders first
+	Origins []OriginStats `json:"origins"`
+}
+
+// analyse many traces, traces without observations are ignored
+func Analyze(traces []*merkle.MerkleTrace) *Report {
+	byOrigin := map[string]*OriginStats{}
+	latencies := map[string][]time.Duration{}
+
+	report := &Report{
+		Origins: []OriginStats{},
+	}
+
+	for _, trace := range traces {
+		timeline := Timeline(trace)
+
+		if len(timeline.Timeline) == 0 {
+			continue
+		}
+
+		report.Traces++
+
+		for _, entry := range timeline.Timeline {
+			s, ok := byOrigin[entry.Origin]
+
+			if !ok {
+				s = &OriginStats{
+					Origin:     entry.Origin,
+					RankCounts: map[int]int{},
+				}
+				byOrigin[entry.Origin] = s
+			}
+
+			s.Traces++
+			s.RankCounts[entry.Rank]++
+			s.MeanRank += float64(entry.Rank)
+
+			latencies[entry.Origin] = append(latencies[entry.Origin], entry.SinceFirstSeen)
+		}
+	}
+
+	for origin, s := range byOrigin {
+		s.MeanRank /= float64(s.Traces)
+		s.LeadRate = float64(s.RankCounts[1]) / float64(s.Traces)
+		s.Latency = percentiles(latencies[origin])
+
+		report.Origins = append(report.Origins, *s)
+	}
+
+	sort.Slice(report.Origins, func(i, j int) bool {
+		a, b := report.Origins[i], report.Origins[j]
+
+		if a.LeadRate != b.LeadRate {
+			return a.LeadRate > b.LeadRate
+		}
+
+		if a.MeanRank != b.MeanRank {
+			return a.MeanRank < b.MeanRank
+		}
+
+		return a.Origin < b.Origin
+	})
+
+	return report
+}
+
+// the origins that were first in at least minLeadRate of the traces they
+// observed, among the ones that observed at least minTraces traces
+func (r *Report) Leaders(minTraces int, minLeadRate float64) []OriginStats {
+	leaders := []OriginStats{}
+
+	for _, origin := range r.Origins {
+		if origin.Traces >= minTraces && origin.LeadRate >= minLeadRate {
+			leaders = append(leaders, origin)
+		}
+	}
+
+	return leaders
+}
+
+func (r *Report) WriteJSON(w io.Writer) error {
+	return json.NewEncoder(w).Encode(r)
+}
+
+// one row per origin, durations in milliseconds. The rank columns
+// count how many times the origin was first, second and third
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+
+	writer.Write([]string{"origin", "traces", "lead_rate", "mean_rank", "rank_1", "rank_2", "rank_3", "p50_ms", "p90_ms", "p99_ms", "max_ms"})
+
+	for _, origin := range r.Origins {
+		writer.Write([]string{
+			origin.Origin,
+			strconv.Itoa(origin.Traces),
+			strconv.FormatFloat(origin.LeadRate, 'f', 4, 64),
+			strconv.FormatFloat(origin.MeanRank, 'f', 2, 64),
+			strconv.Itoa(origin.RankCounts[1]),
+			strconv.Itoa(origin.RankCounts[2]),
+			strconv.Itoa(origin.RankCounts[3]),
+			formatMs(origin.Latency.P50),
+			formatMs(origin.Latency.P90),
+			formatMs(origin.Latency.P99),
+			formatMs(origin.Latency.Max),
+		})
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// nearest rank percentiles
+func percentiles(durations []time.Duration) Percentiles {
+	if len(durations) == 0 {
+		return Percentiles{}
+	}
+
+	sorted := stats.Sorted(durations)
+
+	return Percentiles{
+		P50: stats.Percentile(sorted, 50),
+		P90: stats.Percentile(sorted, 90),
+		P99: stats.Percentile(sorted, 99),
+		Max: sorted[len(sorted)-1],
+	}
+}
+
+func formatMs(d time.Duration) string {
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
+}
@@ -0,0 +1,138 @@
+package analytics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+var start = time.Unix(1700000000, 0)
+
+// an origin observing a transaction, milliseconds after start
+func at(origin string, ms int) merkle.Observation {
+	return merkle.Observation{Origin: origin, Time: start.Add(time.Duration(ms) * time.Millisecond)}
+}
+
+func testTrace(hash string, observations ...merkle.Observation) *merkle.MerkleTrace {
+	return &merkle.MerkleTrace{Hash: hash, ChainId: merkle.EthereumMainnet, FirstSeenAt: start, Trace: observations}
+}
+
+func TestTimeline(t *testing.T) {
+	// out of order, and b observes it twice
+	report := Timeline(testTrace("0x01", at("b", 30), at("a", 10), at("b", 20), at("c", 50)))
+
+	want := []TimelineEntry{
+		{Origin: "a", Time: start.Add(10 * time.Millisecond), Rank: 1},
+		{Origin: "b", Time: start.Add(20 * time.Millisecond), Rank: 2, SinceFirstSeen: 10 * time.Millisecond, SincePrevious: 10 * time.Millisecond},
+		{Origin: "c", Time: start.Add(50 * time.Millisecond), Rank: 3, SinceFirstSeen: 40 * time.Millisecond, SincePrevious: 30 * time.Millisecond},
+	}
+
+	if !reflect.DeepEqual(report.Timeline, want) {
+		t.Fatalf("got timeline %+v", report.Timeline)
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	report := Analyze([]*merkle.MerkleTrace{
+		testTrace("0x01", at("a", 0), at("b", 10), at("c", 20)),
+		testTrace("0x02", at("b", 0), at("a", 40)),
+		testTrace("0x03", at("a", 0), at("c", 30)),
+		testTrace("0x04", at("a", 0), at("b", 20)),
+		// no observations, not counted
+		testTrace("0x05"),
+	})
+
+	if report.Traces != 4 {
+		t.Fatalf("got %d traces, want 4", report.Traces)
+	}
+
+	tests := []OriginStats{
+		{
+			Origin:     "a",
+			Traces:     4,
+			RankCounts: map[int]int{1: 3, 2: 1},
+			MeanRank:   1.25,
+			LeadRate:   0.75,
+			Latency:    Percentiles{P50: 0, P90: 40 * time.Millisecond, P99: 40 * time.Millisecond, Max: 40 * time.Millisecond},
+		},
+		{
+			Origin:     "b",
+			Traces:     3,
+			RankCounts: map[int]int{1: 1, 2: 2},
+			MeanRank:   5.0 / 3,
+			LeadRate:   1.0 / 3,
+			Latency:    Percentiles{P50: 10 * time.Millisecond, P90: 20 * time.Millisecond, P99: 20 * time.Millisecond, Max: 20 * time.Millisecond},
+		},
+		{
+			Origin:     "c",
+			Traces:     2,
+			RankCounts: map[int]int{2: 1, 3: 1},
+			MeanRank:   2.5,
+			LeadRate:   0,
+			Latency:    Percentiles{P50: 20 * time.Millisecond, P90: 30 * time.Millisecond, P99: 30 * time.Millisecond, Max: 30 * time.Millisecond},
+		},
+	}
+
+	if len(report.Origins) != len(tests) {
+		t.Fatalf("got %d origins, want %d", len(report.Origins), len(tests))
+	}
+
+	// sorted by lead rate
+	for i, want := range tests {
+		if got := report.Origins[i]; !reflect.DeepEqual(got, want) {
+			t.Errorf("origin %d: got %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func TestAnalyzeOrdersTies(t *testing.T) {
+	// b and c never lead, c ranks better, a and d tie on everything
+	report := Analyze([]*merkle.MerkleTrace{
+		testTrace("0x01", at("d", 0), at("c", 10), at("b", 20)),
+		testTrace("0x02", at("a", 0), at("c", 10), at("b", 20)),
+	})
+
+	origins := []string{}
+
+	for _, origin := range report.Origins {
+		origins = append(origins, origin.Origin)
+	}
+
+	if !reflect.DeepEqual(origins, []string{"a", "d", "c", "b"}) {
+		t.Fatalf("got origins %v", origins)
+	}
+}
+
+func TestLeaders(t *testing.T) {
+	report := &Report{Origins: []OriginStats{
+		{Origin: "a", Traces: 10, LeadRate: 0.8},
+		{Origin: "b", Traces: 2, LeadRate: 1},
+		{Origin: "c", Traces: 10, LeadRate: 0.5},
+	}}
+
+	tests := []struct {
+		minTraces   int
+		minLeadRate float64
+		want        []string
+	}{
+		{0, 0, []string{"a", "b", "c"}},
+		{5, 0, []string{"a", "c"}},
+		{0, 0.8, []string{"a", "b"}},
+		{5, 0.8, []string{"a"}},
+		{20, 0, []string{}},
+	}
+
+	for _, test := range tests {
+		got := []string{}
+
+		for _, origin := range report.Leaders(test.minTraces, test.minLeadRate) {
+			got = append(got, origin.Origin)
+		}
+
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Leaders(%d, %v) = %v, want %v", test.minTraces, test.minLeadRate, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Package bridge shares merkle streams between services over NATS, so only
+// one connection to merkle is opened for the whole stack.
+package bridge
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+type Options struct {
+	// the first token of every subject, defaults to "merkle"
+	Prefix string
+
+	// add the destination address as the last token of every subject,
+	// so consumers can subscribe to a single contract. Publishers and
+	// consumers of the same subjects must agree on it.
+	ByDestination bool
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.Prefix == "" {
+		opts.Prefix = "merkle"
+	}
+
+	return opts
+}
+
+// the destination token of contract creations
+const CreationDestination = "create"
+
+// the destination token of auctions hiding their destination
+const HiddenDestination = "hidden"
+
+const (
+	transactionsKind = "txs"
+	auctionsKind     = "auctions"
+)
+
+// subject of a stream, without destination
+func (o Options) base(kind string, chainId merkle.MerkleChainId) string {
+	return fmt.Sprintf("%s.%s.%d", o.Prefix, kind, int64(chainId))
+}
+
+// subject of a stream item, e.g. merkle.txs.1 or merkle.txs.1.0xabc...
+func (o Options) subject(kind string, chainId merkle.MerkleChainId, to *common.Address) string {
+	subject := o.base(kind, chainId)
+
+	if !o.ByDestination {
+		return subject
+	}
+
+	if to == nil {
+		return subject + "." + CreationDestination
+	}
+
+	return subject + "." + strings.ToLower(to.Hex())
+}
+
+// subjects to subscribe to, for every destination, or only some
+func (o Options) subscriptionSubjects(kind string, chainId merkle.MerkleChainId, destinations []common.Address) ([]string, error) {
+	if len(destinations) == 0 {
+		if o.ByDestination {
+			return []string{o.base(kind, chainId) + ".*"}, nil
+		}
+
+		return []string{o.base(kind, chainId)}, nil
+	}
+
+	if !o.ByDestination {
+		return nil, fmt.Errorf("filtering by destination requires ByDestination")
+	}
+
+	subjects := []string{}
+
+	for i := range destinations {
+		subjects = append(subjects, o.subject(kind, chainId, &destinations[i]))
+	}
+
+	return subjects, nil
+}
@@ -0,0 +1,273 @@
+package bridge
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+var (
+	router = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	pair   = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+)
+
+// a connection to an embedded NATS server, both closed with the test
+func testConn(t *testing.T) *nats.Conn {
+	t.Helper()
+
+	ns, err := server.NewServer(&server.Options{Host: "127.0.0.1", Port: server.RANDOM_PORT})
+
+	if err != nil {
+		t.Fatalf("failed to create nats server: %s", err)
+	}
+
+	go ns.Start()
+	t.Cleanup(ns.Shutdown)
+
+	if !ns.ReadyForConnections(5 * time.Second) {
+		t.Fatalf("nats server not ready")
+	}
+
+	conn, err := nats.Connect(ns.ClientURL())
+
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+
+	t.Cleanup(conn.Close)
+
+	return conn
+}
+
+// publish items through a subscription fed by the test
+func publish[T any](t *testing.T, run func(ctx context.Context, sub *merkle.Subscription[T]) error, items ...T) {
+	t.Helper()
+
+	sub := merkle.NewSubscription(make(chan T), make(chan error))
+	done := make(chan error, 1)
+
+	go func() {
+		done <- run(context.Background(), sub)
+	}()
+
+	for _, item := range items {
+		sub.Push(item)
+	}
+
+	sub.Close()
+
+	if err := <-done; err != nil {
+		t.Fatalf("failed to publish: %s", err)
+	}
+}
+
+// no item is received for a while
+func quiet[T any](t *testing.T, sub *merkle.Subscription[T]) {
+	t.Helper()
+
+	select {
+	case item := <-sub.Items():
+		t.Fatalf("unexpected item: %v", item)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+// the subject of the next message of a raw subscription
+func nextSubject(t *testing.T, sub *nats.Subscription) string {
+	t.Helper()
+
+	msg, err := sub.NextMsg(5 * time.Second)
+
+	if err != nil {
+		t.Fatalf("no message received: %s", err)
+	}
+
+	return msg.Subject
+}
+
+func testAuction(id string, to *common.Address, hints ...merkle.Hint) *merkle.Auction {
+	return &merkle.Auction{
+		Id:        id,
+		ChainId:   int64(merkle.EthereumMainnet),
+		ClosesAt:  time.Unix(1700000000, 0),
+		CreatedAt: time.Unix(1699999999, 0),
+		Transaction: &merkle.AuctionTransaction{
+			Hash:  common.HexToHash("0x01"),
+			To:    to,
+			Hints: append([]merkle.Hint{merkle.HintHash}, hints...),
+		},
+	}
+}
+
+func TestTransactions(t *testing.T) {
+	conn := testConn(t)
+
+	consumer := NewConsumer(conn, nil)
+
+	sub, err := consumer.Transactions(merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	defer sub.Close()
+
+	conn.Flush()
+
+	txs := []*types.Transaction{testutil.Transaction(0, &router), testutil.Transaction(1, nil)}
+
+	publish(t, func(ctx context.Context, s *merkle.Subscription[*types.Transaction]) error {
+		return NewPublisher(conn, nil).PublishTransactions(ctx, merkle.EthereumMainnet, s)
+	}, txs...)
+
+	for _, tx := range txs {
+		if got := testutil.Next(t, sub.Items(), sub.Err()); got.Hash() != tx.Hash() {
+			t.Fatalf("got transaction %s, want %s", got.Hash(), tx.Hash())
+		}
+	}
+}
+
+func TestTransactionsByDestination(t *testing.T) {
+	conn := testConn(t)
+
+	options := &Options{ByDestination: true}
+	consumer := NewConsumer(conn, options)
+
+	all, err := consumer.Transactions(merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	defer all.Close()
+
+	routed, err := consumer.Transactions(merkle.EthereumMainnet, router)
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	defer routed.Close()
+
+	raw, err := conn.SubscribeSync("merkle.txs.1.>")
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	conn.Flush()
+
+	txs := []*types.Transaction{testutil.Transaction(0, &pair), testutil.Transaction(1, &router), testutil.Transaction(2, nil)}
+
+	publish(t, func(ctx context.Context, s *merkle.Subscription[*types.Transaction]) error {
+		return NewPublisher(conn, options).PublishTransactions(ctx, merkle.EthereumMainnet, s)
+	}, txs...)
+
+	for _, want := range []string{
+		"merkle.txs.1.0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc",
+		"merkle.txs.1.0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+		"merkle.txs.1.create",
+	} {
+		if got := nextSubject(t, raw); got != want {
+			t.Fatalf("published on %s, want %s", got, want)
+		}
+	}
+
+	for _, tx := range txs {
+		if got := testutil.Next(t, all.Items(), all.Err()); got.Hash() != tx.Hash() {
+			t.Fatalf("got transaction %s, want %s", got.Hash(), tx.Hash())
+		}
+	}
+
+	if got := testutil.Next(t, routed.Items(), routed.Err()); got.Hash() != txs[1].Hash() {
+		t.Fatalf("got transaction %s, want %s", got.Hash(), txs[1].Hash())
+	}
+
+	quiet(t, routed)
+}
+
+func TestAuctionsByDestination(t *testing.T) {
+	conn := testConn(t)
+
+	options := &Options{ByDestination: true}
+	consumer := NewConsumer(conn, options)
+
+	all, err := consumer.Auctions(merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	defer all.Close()
+
+	routed, err := consumer.Auctions(merkle.EthereumMainnet, router)
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	defer routed.Close()
+
+	raw, err := conn.SubscribeSync("merkle.auctions.1.>")
+
+	if err != nil {
+		t.Fatalf("failed to subscribe: %s", err)
+	}
+
+	conn.Flush()
+
+	auctions := []*merkle.Auction{
+		testAuction("revealed", &router, merkle.HintTo),
+		testAuction("hidden", nil),
+		testAuction("creation", nil, merkle.HintTo),
+	}
+
+	publish(t, func(ctx context.Context, s *merkle.Subscription[*merkle.Auction]) error {
+		return NewPublisher(conn, options).PublishAuctions(ctx, s)
+	}, auctions...)
+
+	for _, want := range []string{
+		"merkle.auctions.1.0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+		"merkle.auctions.1.hidden",
+		"merkle.auctions.1.create",
+	} {
+		if got := nextSubject(t, raw); got != want {
+			t.Fatalf("published on %s, want %s", got, want)
+		}
+	}
+
+	for _, auction := range auctions {
+		got := testutil.Next(t, all.Items(), all.Err())
+
+		if got.Id != auction.Id {
+			t.Fatalf("got auction %s, want %s", got.Id, auction.Id)
+		}
+
+		if got.Reveals(merkle.HintTo) != auction.Reveals(merkle.HintTo) {
+			t.Fatalf("auction %s: destination revealed %v, want %v", got.Id, got.Reveals(merkle.HintTo), auction.Reveals(merkle.HintTo))
+		}
+	}
+
+	got := testutil.Next(t, routed.Items(), routed.Err())
+
+	if got.Id != "revealed" || got.Transaction.To == nil || *got.Transaction.To != router {
+		t.Fatalf("got auction %s to %v", got.Id, got.Transaction.To)
+	}
+
+	quiet(t, routed)
+}
+
+func TestDestinationsRequireByDestination(t *testing.T) {
+	conn := testConn(t)
+
+	if _, err := NewConsumer(conn, nil).Transactions(merkle.EthereumMainnet, router); err == nil {
+		t.Fatalf("filtering by destination without ByDestination should fail")
+	}
+}
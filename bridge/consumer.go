@@ -0,0 +1,100 @@
+package bridge
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	"github.com/nats-io/nats.go"
+)
+
+// turns bridged NATS subjects back into merkle subscriptions
+type Consumer struct {
+	conn    *nats.Conn
+	options Options
+}
+
+func NewConsumer(conn *nats.Conn, options *Options) *Consumer {
+	return &Consumer{
+		conn:    conn,
+		options: options.withDefaults(),
+	}
+}
+
+// subscribe to the bridged transactions of a chain, optionally only
+// the ones sent to some destinations (requires ByDestination)
+func (c *Consumer) Transactions(chainId merkle.MerkleChainId, destinations ...common.Address) (*merkle.Subscription[*types.Transaction], error) {
+	return subscribe(c, transactionsKind, chainId, destinations, func(data []byte) (*types.Transaction, error) {
+		tx := new(types.Transaction)
+
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("error decoding transaction: %s", err)
+		}
+
+		return tx, nil
+	})
+}
+
+// subscribe to the bridged auctions of a chain, optionally only
+// the ones sent to some destinations (requires ByDestination)
+func (c *Consumer) Auctions(chainId merkle.MerkleChainId, destinations ...common.Address) (*merkle.Subscription[*merkle.Auction], error) {
+	return subscribe(c, auctionsKind, chainId, destinations, func(data []byte) (*merkle.Auction, error) {
+		var raw merkle.RawAuction
+
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("error decoding auction: %s", err)
+		}
+
+		return raw.Auction()
+	})
+}
+
+func subscribe[T any](c *Consumer, kind string, chainId merkle.MerkleChainId, destinations []common.Address, decode func([]byte) (T, error)) (*merkle.Subscription[T], error) {
+	subjects, err := c.options.subscriptionSubjects(kind, chainId, destinations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	sub := merkle.NewSubscription(make(chan T), make(chan error))
+
+	handler := func(msg *nats.Msg) {
+		item, err := decode(msg.Data)
+
+		if err != nil {
+			sub.PushError(err)
+			return
+		}
+
+		sub.Push(item)
+	}
+
+	natsSubs := []*nats.Subscription{}
+
+	unsubscribe := func() error {
+		for _, natsSub := range natsSubs {
+			if err := natsSub.Unsubscribe(); err != nil && err != nats.ErrConnectionClosed {
+				return fmt.Errorf("error unsubscribing: %s", err)
+			}
+		}
+
+		return nil
+	}
+
+	for _, subject := range subjects {
+		natsSub, err := c.conn.Subscribe(subject, handler)
+
+		if err != nil {
+			unsubscribe()
+			return nil, fmt.Errorf("error subscribing to %s: %s", subject, err)
+		}
+
+		natsSubs = append(natsSubs, natsSub)
+	}
+
+	sub.OnClose(unsubscribe)
+
+	return sub, nil
+}
@@ -0,0 +1,117 @@
+package bridge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	"github.com/nats-io/nats.go"
+)
+
+// publishes merkle streams to NATS subjects
+type Publisher struct {
+	conn    *nats.Conn
+	options Options
+
+	// called with the errors of the published streams, optional
+	OnError func(err error)
+}
+
+func NewPublisher(conn *nats.Conn, options *Options) *Publisher {
+	return &Publisher{
+		conn:    conn,
+		options: options.withDefaults(),
+	}
+}
+
+func (p *Publisher) streamError(err error) {
+	if p.OnError != nil {
+		p.OnError(err)
+	}
+}
+
+// publish every transaction of a subscription, as the raw binary
+// transaction, until the context is done or the subscription ends
+func (p *Publisher) PublishTransactions(ctx context.Context, chainId merkle.MerkleChainId, sub *merkle.Subscription[*types.Transaction]) error {
+	errs := sub.Err()
+
+	for {
+		select {
+		case tx, ok := <-sub.Items():
+			if !ok {
+				return nil
+			}
+
+			payload, err := tx.MarshalBinary()
+
+			if err != nil {
+				p.streamError(fmt.Errorf("error marshalling transaction: %s", err))
+				continue
+			}
+
+			if err := p.conn.Publish(p.options.subject(transactionsKind, chainId, tx.To()), payload); err != nil {
+				return fmt.Errorf("error publishing transaction: %s", err)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			p.streamError(err)
+		case <-sub.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// publish every auction of a subscription, in the json format of the
+// merkle auction feed, until the context is done or the subscription ends
+func (p *Publisher) PublishAuctions(ctx context.Context, sub *merkle.Subscription[*merkle.Auction]) error {
+	errs := sub.Err()
+
+	for {
+		select {
+		case auction, ok := <-sub.Items():
+			if !ok {
+				return nil
+			}
+
+			payload, err := json.Marshal(auction.Raw())
+
+			if err != nil {
+				p.streamError(fmt.Errorf("error marshalling auction: %s", err))
+				continue
+			}
+
+			subject := p.options.subject(auctionsKind, merkle.MerkleChainId(auction.ChainId), nil)
+
+			if auction.Transaction != nil {
+				subject = p.options.subject(auctionsKind, merkle.MerkleChainId(auction.ChainId), auction.Transaction.To)
+			}
+
+			if p.options.ByDestination && !auction.Reveals(merkle.HintTo) {
+				subject = p.options.base(auctionsKind, merkle.MerkleChainId(auction.ChainId)) + "." + HiddenDestination
+			}
+
+			if err := p.conn.Publish(subject, payload); err != nil {
+				return fmt.Errorf("error publishing auction: %s", err)
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			p.streamError(err)
+		case <-sub.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
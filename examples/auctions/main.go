@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
@@ -12,14 +13,36 @@ func main() {
 
 	merkleSdk.SetApiKey(os.Getenv("MERKLE_API_KEY"))
 
-	auctions, err := merkleSdk.Pool().Auctions()
+	auctions := merkleSdk.Pool().Auctions(context.Background(), nil)
+	defer auctions.Close()
+
+	states := auctions.States()
+	errs := auctions.Err()
 
 	for {
 		select {
-		case e := <-err:
-			// error happened
+		case state, ok := <-states:
+			if !ok {
+				states = nil
+				continue
+			}
+
+			// the connection is re-opened when it's lost
+			fmt.Printf("connection %s (attempt %d): %v\n", state.State, state.Attempt, state.Err)
+		case e, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			// skipped messages and lost connections, the stream carries on
 			fmt.Printf("error: %v\n", e)
-		case auction := <-auctions:
+		case auction, ok := <-auctions.Items():
+			if !ok {
+				// closed, or gave up reconnecting
+				return
+			}
+
 			// process the transaction
 			fmt.Printf("auction tx: %+v\n", auction.Transaction)
 		}
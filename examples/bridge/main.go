@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/joho/godotenv"
+	"github.com/merkle3/merkle-sdk-go/bridge"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	"github.com/nats-io/nats.go"
+)
+
+func main() {
+	godotenv.Load()
+
+	// the NATS server shared by the services
+	url := os.Getenv("NATS_URL")
+
+	if url == "" {
+		url = nats.DefaultURL
+	}
+
+	conn, err := nats.Connect(url)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer conn.Close()
+
+	options := &bridge.Options{ByDestination: true}
+
+	// one service streams from merkle and publishes to NATS
+	merkleSdk := merkle.New()
+
+	merkleSdk.SetApiKey(os.Getenv("MERKLE_API_KEY"))
+
+	txs, errs := merkleSdk.Transactions().Stream(merkle.EthereumMainnet)
+
+	publisher := bridge.NewPublisher(conn, options)
+	publisher.OnError = func(err error) {
+		fmt.Printf("stream error: %v\n", err)
+	}
+
+	go publisher.PublishTransactions(context.Background(), merkle.EthereumMainnet, merkle.NewSubscription(txs, errs))
+
+	// other services consume from NATS
+	sub, err := bridge.NewConsumer(conn, options).Transactions(merkle.EthereumMainnet)
+
+	if err != nil {
+		panic(err)
+	}
+
+	defer sub.Close()
+
+	for {
+		select {
+		case e := <-sub.Err():
+			// error happened
+			fmt.Printf("error: %v\n", e)
+		case tx := <-sub.Items():
+			// process the transaction
+			fmt.Printf("hash: %v\n", tx.Hash().String())
+		}
+	}
+}
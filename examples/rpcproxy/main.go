@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/joho/godotenv"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	"github.com/merkle3/merkle-sdk-go/rpcproxy"
+)
+
+func main() {
+	godotenv.Load()
+
+	merkleSdk := merkle.New()
+
+	merkleSdk.SetApiKey(os.Getenv("MERKLE_API_KEY"))
+
+	proxy, err := rpcproxy.New(merkleSdk, rpcproxy.Config{
+		Upstream:     os.Getenv("UPSTREAM_RPC"),
+		DefaultRoute: rpcproxy.RoutePool,
+		Rules: []rpcproxy.Rule{
+			{
+				// e.g. a bot that needs its transactions in the public mempool
+				Senders: []common.Address{common.HexToAddress("0x3b42a0ed9050A79d8F35B07021272B3ef073266A")},
+				Route:   rpcproxy.RouteInject,
+			},
+		},
+		Source: "rpcproxy",
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println("point your wallet to http://localhost:8545")
+
+	if err := http.ListenAndServe("localhost:8545", proxy); err != nil {
+		panic(err)
+	}
+}
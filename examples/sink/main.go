@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+func main() {
+	godotenv.Load()
+
+	merkleSdk := merkle.New()
+
+	merkleSdk.SetApiKey(os.Getenv("MERKLE_API_KEY"))
+
+	// write the mempool into gzipped json lines, one file per hour
+	sink, err := merkle.NewJSONLSink(merkle.FileSinkOptions{
+		Path:     "txs.jsonl",
+		MaxAge:   1 * time.Hour,
+		Compress: true,
+	})
+
+	if err != nil {
+		panic(err)
+	}
+
+	sub, err := merkleSdk.Transactions().StreamToSink(merkle.EthereumMainnet, sink, nil)
+
+	if err != nil {
+		sink.Close()
+		panic(err)
+	}
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	for {
+		select {
+		case e := <-sub.Err():
+			// error happened
+			fmt.Printf("error: %v\n", e)
+		case tx := <-sub.Items():
+			fmt.Printf("hash: %v\n", tx.Hash().String())
+		case <-interrupt:
+			// flush the sink before exiting
+			if err := sub.Close(); err != nil {
+				fmt.Printf("error: %v\n", err)
+			}
+			return
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// Package grpctransport streams transactions and auctions over gRPC instead
+// of websockets. Select it with merkle.MerkleSDK.SetTransport.
+package grpctransport
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	merklev1 "github.com/merkle3/merkle-sdk-go/proto/merkle/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// the default merkle gRPC endpoint
+const DefaultTarget = "grpc.merkle.io:443"
+
+type Options struct {
+	// disable TLS, e.g. for a local reference server
+	Insecure bool
+
+	// ping the server after this long without activity, defaults to 30 seconds
+	KeepaliveTime time.Duration
+
+	// close the connection if a ping isn't answered in time, defaults to 10 seconds
+	KeepaliveTimeout time.Duration
+
+	// flow control windows, how many bytes the server can send before
+	// the client reads them, default to 1MB per stream and 4MB per connection
+	InitialWindowSize     int32
+	InitialConnWindowSize int32
+
+	// extra dial options
+	DialOptions []grpc.DialOption
+}
+
+func (o *Options) withDefaults() Options {
+	opts := Options{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.KeepaliveTime <= 0 {
+		opts.KeepaliveTime = 30 * time.Second
+	}
+
+	if opts.KeepaliveTimeout <= 0 {
+		opts.KeepaliveTimeout = 10 * time.Second
+	}
+
+	if opts.InitialWindowSize <= 0 {
+		opts.InitialWindowSize = 1 << 20
+	}
+
+	if opts.InitialConnWindowSize <= 0 {
+		opts.InitialConnWindowSize = 4 << 20
+	}
+
+	return opts
+}
+
+// a merkle.Transport over gRPC
+type Transport struct {
+	conn   *grpc.ClientConn
+	client merklev1.MerkleServiceClient
+}
+
+// connect to a merkle gRPC server, e.g. DefaultTarget
+func Dial(ctx context.Context, target string, options *Options) (*Transport, error) {
+	opts := options.withDefaults()
+
+	creds := credentials.NewTLS(nil)
+
+	if opts.Insecure {
+		creds = insecure.NewCredentials()
+	}
+
+	dialOptions := append([]grpc.DialOption{
+		grpc.WithTransportCredentials(creds),
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                opts.KeepaliveTime,
+			Timeout:             opts.KeepaliveTimeout,
+			PermitWithoutStream: true,
+		}),
+		grpc.WithInitialWindowSize(opts.InitialWindowSize),
+		grpc.WithInitialConnWindowSize(opts.InitialConnWindowSize),
+	}, opts.DialOptions...)
+
+	conn, err := grpc.DialContext(ctx, target, dialOptions...)
+
+	if err != nil {
+		return nil, fmt.Errorf("error dialing %s: %s", target, err)
+	}
+
+	return New(conn), nil
+}
+
+// a transport over an existing connection
+func New(conn *grpc.ClientConn) *Transport {
+	return &Transport{
+		conn:   conn,
+		client: merklev1.NewMerkleServiceClient(conn),
+	}
+}
+
+func (t *Transport) Close() error {
+	return t.conn.Close()
+}
+
+func withApiKey(ctx context.Context, apiKey string) context.Context {
+	return metadata.AppendToOutgoingContext(ctx, "authorization", "Token "+apiKey)
+}
+
+// a server stream
+type receiver[M any] interface {
+	Recv() (M, error)
+}
+
+// open a server stream and receive from it, reopening it when it fails like
+// the websocket transport does, until 5 attempts in a row fail to open it
+func stream[T any, M any](ctx context.Context, open func(ctx context.Context) (receiver[M], error), decode func(M) (T, error)) *merkle.Subscription[T] {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := merkle.NewSubscription(make(chan T), make(chan error))
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
+
+	go func() {
+		defer sub.Close()
+
+		retries := 0
+
+		for {
+			retries++
+
+			s, err := open(ctx)
+
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+
+				if retries < 5 && sleep(ctx, 1*time.Second) {
+					continue
+				}
+
+				sub.PushError(err)
+				return
+			}
+
+			// reset the retries
+			retries = 0
+
+			for {
+				msg, err := s.Recv()
+
+				if err != nil {
+					// the server won't accept the stream, no point retrying
+					if isPermanent(err) && ctx.Err() == nil {
+						sub.PushError(fmt.Errorf("stream rejected: %s", err))
+						return
+					}
+
+					// try to reopen the stream
+					break
+				}
+
+				item, err := decode(msg)
+
+				if err != nil {
+					// report the item we couldn't decode and skip it
+					sub.PushError(fmt.Errorf("skipping message: %s", err))
+					continue
+				}
+
+				if !sub.Push(item) {
+					return
+				}
+			}
+
+			if !sleep(ctx, 1*time.Second) {
+				return
+			}
+		}
+	}()
+
+	return sub
+}
+
+func isPermanent(err error) bool {
+	switch status.Code(err) {
+	case codes.Unauthenticated, codes.PermissionDenied, codes.InvalidArgument, codes.Unimplemented:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleep for a duration, returns false if the context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+func (t *Transport) Transactions(ctx context.Context, apiKey string, chainId merkle.MerkleChainId) (*merkle.Subscription[[]byte], error) {
+	open := func(ctx context.Context) (receiver[*merklev1.Transaction], error) {
+		return t.client.StreamTransactions(withApiKey(ctx, apiKey), &merklev1.StreamTransactionsRequest{
+			ChainId: int64(chainId),
+		})
+	}
+
+	return stream(ctx, open, func(msg *merklev1.Transaction) ([]byte, error) {
+		return msg.Raw, nil
+	}), nil
+}
+
+func (t *Transport) Auctions(ctx context.Context, apiKey string) (*merkle.Subscription[*merkle.Auction], error) {
+	open := func(ctx context.Context) (receiver[*merklev1.Auction], error) {
+		return t.client.StreamAuctions(withApiKey(ctx, apiKey), &merklev1.StreamAuctionsRequest{})
+	}
+
+	return stream(ctx, open, auctionFromProto), nil
+}
+
+// send a bid for the auction of a transaction hash, returns the bid id
+func (t *Transport) SendBid(ctx context.Context, apiKey string, hash string, txs []*types.Transaction) (string, error) {
+	bid := &merklev1.Bid{
+		Hash: hash,
+	}
+
+	for _, tx := range txs {
+		raw, err := tx.MarshalBinary()
+
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal transaction: %s", err)
+		}
+
+		bid.Txs = append(bid.Txs, raw)
+	}
+
+	res, err := t.client.SendBid(withApiKey(ctx, apiKey), bid)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to send bid: %s", err)
+	}
+
+	return res.BidId, nil
+}
+
+// simulate a bundle of calls
+func (t *Transport) Simulate(ctx context.Context, apiKey string, bundle *merkle.SimulationBundle) (*merkle.SimulationResult, error) {
+	res, err := t.client.Simulate(withApiKey(ctx, apiKey), simulationToProto(bundle))
+
+	if err != nil {
+		return nil, fmt.Errorf("error sending request: %s", err)
+	}
+
+	return simulationFromProto(res)
+}
@@ -0,0 +1,375 @@
+package grpctransport
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/big"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	merklev1 "github.com/merkle3/merkle-sdk-go/proto/merkle/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+const testApiKey = "test-key"
+
+// a transport connected to the reference server over an in memory listener,
+// both closed with the test
+func testTransport(t *testing.T, server *Server, options ...grpc.ServerOption) *Transport {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+
+	srv := server.GRPCServer(options...)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	transport, err := Dial(context.Background(), "bufnet", &Options{
+		Insecure: true,
+		DialOptions: []grpc.DialOption{
+			grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+				return lis.DialContext(ctx)
+			}),
+		},
+	})
+
+	if err != nil {
+		t.Fatalf("failed to dial: %s", err)
+	}
+
+	t.Cleanup(func() { transport.Close() })
+
+	return transport
+}
+
+// wait for the server to have a number of open streams, items
+// published before a stream is open don't reach it
+func waitStreams(t *testing.T, server *Server, transactions int, auctions int) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for time.Now().Before(deadline) {
+		server.mu.Lock()
+		ready := len(server.transactions) == transactions && len(server.auctions) == auctions
+		server.mu.Unlock()
+
+		if ready {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("the server doesn't have %d transaction and %d auction streams", transactions, auctions)
+}
+
+// the next error of a subscription
+func nextErr[T any](t *testing.T, sub *merkle.Subscription[T]) error {
+	t.Helper()
+
+	select {
+	case item := <-sub.Items():
+		t.Fatalf("unexpected item: %v", item)
+	case err := <-sub.Err():
+		return err
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no error received")
+	}
+
+	return nil
+}
+
+// wait for a subscription to close itself
+func waitClosed[T any](t *testing.T, sub *merkle.Subscription[T]) {
+	t.Helper()
+
+	select {
+	case <-sub.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the subscription wasn't closed")
+	}
+}
+
+var router = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+
+func TestStreamTransactions(t *testing.T) {
+	server := NewServer()
+	transport := testTransport(t, server)
+
+	sub, err := transport.Transactions(context.Background(), testApiKey, merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+
+	defer sub.Close()
+
+	waitStreams(t, server, 1, 0)
+
+	txs := []*types.Transaction{testutil.Transaction(0, &router), testutil.Transaction(1, &router)}
+
+	// another chain, not streamed
+	server.PublishTransaction(merkle.PolygonMainnet, testutil.Transaction(2, &router))
+
+	for _, tx := range txs {
+		if err := server.PublishTransaction(merkle.EthereumMainnet, tx); err != nil {
+			t.Fatalf("failed to publish: %s", err)
+		}
+	}
+
+	for _, tx := range txs {
+		want, _ := tx.MarshalBinary()
+
+		if got := testutil.Next(t, sub.Items(), sub.Err()); !bytes.Equal(got, want) {
+			t.Fatalf("got transaction %x, want %x", got, want)
+		}
+	}
+}
+
+func TestStreamAuctions(t *testing.T) {
+	server := NewServer()
+	transport := testTransport(t, server)
+
+	sub, err := transport.Auctions(context.Background(), testApiKey)
+
+	if err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+
+	defer sub.Close()
+
+	waitStreams(t, server, 0, 1)
+
+	server.PublishAuction(&merkle.Auction{
+		Id:       "auction",
+		ChainId:  1,
+		ClosesAt: time.Unix(1700000000, 0),
+		Transaction: &merkle.AuctionTransaction{
+			Hash:      common.HexToHash("0x01"),
+			To:        &router,
+			Value:     big.NewInt(5),
+			GasFeeCap: big.NewInt(30e9),
+			GasTipCap: big.NewInt(1e9),
+			Hints:     []merkle.Hint{merkle.HintHash, merkle.HintTo},
+		},
+	})
+
+	auction := testutil.Next(t, sub.Items(), sub.Err())
+
+	if auction.Id != "auction" || !auction.ClosesAt.Equal(time.Unix(1700000000, 0)) {
+		t.Fatalf("got auction %s closing at %s", auction.Id, auction.ClosesAt)
+	}
+
+	if auction.Transaction.To == nil || *auction.Transaction.To != router {
+		t.Fatalf("got destination %v, want %s", auction.Transaction.To, router)
+	}
+
+	// the value isn't revealed
+	if auction.Transaction.Value != nil {
+		t.Fatalf("got hidden value %s", auction.Transaction.Value)
+	}
+
+	if auction.Transaction.GasTipCap == nil || auction.Transaction.GasTipCap.Int64() != 1e9 {
+		t.Fatalf("got tip cap %v", auction.Transaction.GasTipCap)
+	}
+}
+
+func TestStreamRejected(t *testing.T) {
+	server := NewServer()
+	server.ApiKey = testApiKey
+
+	transport := testTransport(t, server)
+
+	sub, err := transport.Transactions(context.Background(), "wrong-key", merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+
+	defer sub.Close()
+
+	err = nextErr(t, sub)
+
+	if !strings.Contains(err.Error(), "stream rejected") || !strings.Contains(err.Error(), codes.Unauthenticated.String()) {
+		t.Fatalf("got error %s", err)
+	}
+
+	// not retried
+	waitClosed(t, sub)
+}
+
+// a server stream whose context can be cancelled by the test
+type droppableStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *droppableStream) Context() context.Context {
+	return s.ctx
+}
+
+// drops the open streams on demand, their handlers return as if the client
+// went away and the client sees the stream end
+type dropper struct {
+	mu      sync.Mutex
+	opened  int
+	cancels []context.CancelFunc
+}
+
+func (d *dropper) intercept(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, cancel := context.WithCancel(ss.Context())
+	defer cancel()
+
+	d.mu.Lock()
+	d.opened++
+	d.cancels = append(d.cancels, cancel)
+	d.mu.Unlock()
+
+	return handler(srv, &droppableStream{ServerStream: ss, ctx: ctx})
+}
+
+func (d *dropper) drop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for _, cancel := range d.cancels {
+		cancel()
+	}
+
+	d.cancels = nil
+}
+
+func (d *dropper) streams() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.opened
+}
+
+func TestStreamReconnects(t *testing.T) {
+	server := NewServer()
+	dropper := &dropper{}
+
+	transport := testTransport(t, server, grpc.StreamInterceptor(dropper.intercept))
+
+	sub, err := transport.Transactions(context.Background(), testApiKey, merkle.EthereumMainnet)
+
+	if err != nil {
+		t.Fatalf("failed to stream: %s", err)
+	}
+
+	defer sub.Close()
+
+	waitStreams(t, server, 1, 0)
+
+	server.PublishTransaction(merkle.EthereumMainnet, testutil.Transaction(0, &router))
+	testutil.Next(t, sub.Items(), sub.Err())
+
+	dropper.drop()
+
+	// the stream is reopened after a second
+	deadline := time.Now().Add(5 * time.Second)
+
+	for dropper.streams() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if dropper.streams() != 2 {
+		t.Fatalf("the stream wasn't reopened")
+	}
+
+	waitStreams(t, server, 1, 0)
+
+	tx := testutil.Transaction(1, &router)
+	want, _ := tx.MarshalBinary()
+
+	server.PublishTransaction(merkle.EthereumMainnet, tx)
+
+	if got := testutil.Next(t, sub.Items(), sub.Err()); !bytes.Equal(got, want) {
+		t.Fatalf("got transaction %x, want %x", got, want)
+	}
+}
+
+// a server stream of canned messages, ended by an error
+type cannedReceiver struct {
+	messages []*merklev1.Auction
+	end      error
+}
+
+func (r *cannedReceiver) Recv() (*merklev1.Auction, error) {
+	if len(r.messages) == 0 {
+		return nil, r.end
+	}
+
+	msg := r.messages[0]
+	r.messages = r.messages[1:]
+
+	return msg, nil
+}
+
+func TestStreamReportsDecodeErrors(t *testing.T) {
+	open := func(ctx context.Context) (receiver[*merklev1.Auction], error) {
+		return &cannedReceiver{
+			messages: []*merklev1.Auction{
+				{Id: "first"},
+				{Id: "invalid", Transaction: &merklev1.AuctionTransaction{Hints: []string{"value"}, Value: "not a number"}},
+				{Id: "second"},
+			},
+			end: status.Error(codes.PermissionDenied, "done"),
+		}, nil
+	}
+
+	sub := stream(context.Background(), open, auctionFromProto)
+	defer sub.Close()
+
+	if got := testutil.Next(t, sub.Items(), sub.Err()); got.Id != "first" {
+		t.Fatalf("got auction %s, want first", got.Id)
+	}
+
+	if err := nextErr(t, sub); !strings.Contains(err.Error(), "skipping message") {
+		t.Fatalf("got error %s", err)
+	}
+
+	if got := testutil.Next(t, sub.Items(), sub.Err()); got.Id != "second" {
+		t.Fatalf("got auction %s, want second", got.Id)
+	}
+
+	if err := nextErr(t, sub); !strings.Contains(err.Error(), "stream rejected") {
+		t.Fatalf("got error %s", err)
+	}
+
+	waitClosed(t, sub)
+}
+
+func TestIsPermanent(t *testing.T) {
+	tests := []struct {
+		err       error
+		permanent bool
+	}{
+		{status.Error(codes.Unauthenticated, ""), true},
+		{status.Error(codes.PermissionDenied, ""), true},
+		{status.Error(codes.InvalidArgument, ""), true},
+		{status.Error(codes.Unimplemented, ""), true},
+		{status.Error(codes.Unavailable, ""), false},
+		{status.Error(codes.Internal, ""), false},
+		{io.EOF, false},
+	}
+
+	for _, test := range tests {
+		if got := isPermanent(test.err); got != test.permanent {
+			t.Errorf("isPermanent(%v) = %v, want %v", test.err, got, test.permanent)
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package grpctransport
+
+import (
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	merklev1 "github.com/merkle3/merkle-sdk-go/proto/merkle/v1"
+)
+
+func transactionToProto(tx *types.Transaction, seenAt time.Time) (*merklev1.Transaction, error) {
+	raw, err := tx.MarshalBinary()
+
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling transaction: %s", err)
+	}
+
+	return &merklev1.Transaction{
+		Raw:            raw,
+		SeenAtUnixNano: seenAt.UnixNano(),
+	}, nil
+}
+
+func auctionToProto(auction *merkle.Auction) *merklev1.Auction {
+	msg := &merklev1.Auction{
+		Id:            auction.Id,
+		FeeRecipient:  auction.FeeRecipient,
+		ChainId:       auction.ChainId,
+		CreatedAtUnix: auction.CreatedAt.Unix(),
+		ClosesAtUnix:  auction.ClosesAt.Unix(),
+	}
+
+	if tx := auction.Transaction; tx != nil {
+		msg.Transaction = &merklev1.AuctionTransaction{
+			Hash:             tx.Hash.String(),
+			Data:             tx.Data,
+			FunctionSelector: tx.FunctionSelector,
+		}
+
+		for _, hint := range tx.Hints {
+			msg.Transaction.Hints = append(msg.Transaction.Hints, string(hint))
+		}
+
+		if tx.From != nil {
+			msg.Transaction.From = tx.From.String()
+		}
+
+		if tx.To != nil {
+			msg.Transaction.To = tx.To.String()
+		}
+
+		if tx.Value != nil {
+			msg.Transaction.Value = tx.Value.String()
+		}
+
+		if tx.Gas != nil {
+			msg.Transaction.Gas = *tx.Gas
+		}
+
+		for _, log := range tx.Logs {
+			msgLog := &merklev1.AuctionLog{
+				Address: log.Address.String(),
+				Data:    log.Data,
+			}
+
+			for _, topic := range log.Topics {
+				msgLog.Topics = append(msgLog.Topics, topic.String())
+			}
+
+			msg.Transaction.Logs = append(msg.Transaction.Logs, msgLog)
+		}
+
+		if tx.GasPrice != nil {
+			msg.Transaction.GasPrice = tx.GasPrice.String()
+		}
+
+		if tx.GasFeeCap != nil {
+			msg.Transaction.MaxFeePerGas = tx.GasFeeCap.String()
+		}
+
+		if tx.GasTipCap != nil {
+			msg.Transaction.MaxPriorityFeePerGas = tx.GasTipCap.String()
+		}
+	}
+
+	return msg
+}
+
+func auctionFromProto(msg *merklev1.Auction) (*merkle.Auction, error) {
+	auction := &merkle.Auction{
+		Id:           msg.Id,
+		FeeRecipient: msg.FeeRecipient,
+		ChainId:      msg.ChainId,
+		CreatedAt:    time.Unix(msg.CreatedAtUnix, 0),
+		ClosesAt:     time.Unix(msg.ClosesAtUnix, 0),
+	}
+
+	if msg.Transaction != nil {
+		tx, err := auctionTransactionFromProto(msg.Transaction)
+
+		if err != nil {
+			return nil, err
+		}
+
+		auction.Transaction = tx
+	}
+
+	return auction, nil
+}
+
+// only the fields the hints reveal are set
+func auctionTransactionFromProto(msg *merklev1.AuctionTransaction) (*merkle.AuctionTransaction, error) {
+	tx := &merkle.AuctionTransaction{
+		Hash:  common.HexToHash(msg.Hash),
+		Hints: []merkle.Hint{merkle.HintHash},
+	}
+
+	for _, hint := range msg.Hints {
+		if merkle.Hint(hint) != merkle.HintHash {
+			tx.Hints = append(tx.Hints, merkle.Hint(hint))
+		}
+	}
+
+	if tx.Reveals(merkle.HintFrom) {
+		from := common.HexToAddress(msg.From)
+		tx.From = &from
+	}
+
+	// an empty destination is a contract creation
+	if tx.Reveals(merkle.HintTo) && msg.To != "" {
+		to := common.HexToAddress(msg.To)
+		tx.To = &to
+	}
+
+	if tx.Reveals(merkle.HintValue) {
+		value, ok := new(big.Int).SetString(msg.Value, 10)
+
+		if !ok {
+			return nil, fmt.Errorf("failed to parse value: %s", msg.Value)
+		}
+
+		tx.Value = value
+	}
+
+	if tx.Reveals(merkle.HintGas) {
+		gas := msg.Gas
+		tx.Gas = &gas
+	}
+
+	if tx.Reveals(merkle.HintCalldata) {
+		tx.Data = msg.Data
+
+		if tx.Data == nil {
+			tx.Data = []byte{}
+		}
+	}
+
+	if tx.Reveals(merkle.HintFunctionSelector) {
+		tx.FunctionSelector = msg.FunctionSelector
+
+		if len(tx.Data) >= 4 {
+			tx.FunctionSelector = tx.Data[:4]
+		}
+	}
+
+	if tx.Reveals(merkle.HintLogs) {
+		tx.Logs = []*merkle.AuctionLog{}
+
+		for _, msgLog := range msg.Logs {
+			log := &merkle.AuctionLog{
+				Address: common.HexToAddress(msgLog.Address),
+				Data:    msgLog.Data,
+			}
+
+			for _, topic := range msgLog.Topics {
+				log.Topics = append(log.Topics, common.HexToHash(topic))
+			}
+
+			tx.Logs = append(tx.Logs, log)
+		}
+	}
+
+	var err error
+
+	if tx.GasPrice, err = feeFromProto("gas price", msg.GasPrice); err != nil {
+		return nil, err
+	}
+
+	if tx.GasFeeCap, err = feeFromProto("max fee per gas", msg.MaxFeePerGas); err != nil {
+		return nil, err
+	}
+
+	if tx.GasTipCap, err = feeFromProto("max priority fee per gas", msg.MaxPriorityFeePerGas); err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// a decimal fee, nil when it isn't shared
+func feeFromProto(name string, value string) (*big.Int, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	fee, ok := new(big.Int).SetString(value, 10)
+
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: %s", name, value)
+	}
+
+	return fee, nil
+}
+
+func overridesToProto(overrides *merkle.StateOverrideParameters) *merklev1.StateOverrides {
+	if overrides == nil {
+		return nil
+	}
+
+	msg := &merklev1.StateOverrides{
+		Accounts:      map[string]*merklev1.AccountOverride{},
+		ContractCodes: overrides.ContractCodes,
+		Storage:       map[string]*merklev1.StorageOverride{},
+	}
+
+	for address, account := range overrides.Accounts {
+		override := &merklev1.AccountOverride{}
+
+		if account.Nonce != nil {
+			nonce := int64(*account.Nonce)
+			override.Nonce = &nonce
+		}
+
+		if account.Balance != nil {
+			balance := int64(*account.Balance)
+			override.Balance = &balance
+		}
+
+		msg.Accounts[address] = override
+	}
+
+	for address, slots := range overrides.Storage {
+		msg.Storage[address] = &merklev1.StorageOverride{Slots: slots}
+	}
+
+	return msg
+}
+
+func simulationToProto(bundle *merkle.SimulationBundle) *merklev1.SimulationRequest {
+	msg := &merklev1.SimulationRequest{
+		ChainId:   int64(bundle.ChainId),
+		Overrides: overridesToProto(bundle.Overrides),
+	}
+
+	if bundle.BlockNumber != nil {
+		block := int64(*bundle.BlockNumber)
+		msg.BlockNumber = &block
+	}
+
+	for _, call := range bundle.Calls {
+		msg.Calls = append(msg.Calls, &merklev1.SimulationCall{
+			From:      call.From,
+			To:        call.To,
+			Value:     call.Value,
+			Nonce:     call.Nonce,
+			Data:      call.Data,
+			GasLimit:  call.GasLimit,
+			Overrides: overridesToProto(call.Overrides),
+		})
+	}
+
+	return msg
+}
+
+func bigIntFromString(s string) (*merkle.BigInt, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	var b merkle.BigInt
+
+	if err := b.UnmarshalJSON([]byte(s)); err != nil {
+		return nil, err
+	}
+
+	return &b, nil
+}
+
+func simulationFromProto(msg *merklev1.SimulationResponse) (*merkle.SimulationResult, error) {
+	blockNumber, err := bigIntFromString(msg.BlockNumber)
+
+	if err != nil {
+		return nil, fmt.Errorf("error decoding block number: %s", err)
+	}
+
+	result := &merkle.SimulationResult{
+		ChainId:     int(msg.ChainId),
+		BlockNumber: blockNumber,
+		ProcessTime: int(msg.ProcessTime),
+		Calls:       []merkle.SimulationCallResult{},
+	}
+
+	for _, call := range msg.Calls {
+		gasUsed, err := bigIntFromString(call.GasUsed)
+
+		if err != nil {
+			return nil, fmt.Errorf("error decoding gas used: %s", err)
+		}
+
+		callResult := merkle.SimulationCallResult{
+			Logs:           []merkle.Log{},
+			GasUsed:        gasUsed,
+			Result:         call.Result,
+			AddressCreated: call.AddressCreated,
+			Status:         int(call.Status),
+		}
+
+		for _, log := range call.Logs {
+			callResult.Logs = append(callResult.Logs, merkle.Log{
+				Address: log.Address,
+				Topics:  log.Topics,
+				Data:    log.Data,
+			})
+		}
+
+		if call.Error != nil {
+			callResult.Error = &merkle.ErrorDetails{
+				Type:    call.Error.Type,
+				Message: call.Error.Message,
+			}
+		}
+
+		for _, transfer := range call.InternalTransfers {
+			amount, err := bigIntFromString(transfer.Amount)
+
+			if err != nil {
+				return nil, fmt.Errorf("error decoding transfer amount: %s", err)
+			}
+
+			callResult.InternalTransfers = append(callResult.InternalTransfers, merkle.InternalTransfer{
+				From:   transfer.From,
+				To:     transfer.To,
+				Amount: amount,
+			})
+		}
+
+		result.Calls = append(result.Calls, callResult)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,257 @@
+package grpctransport
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+	merklev1 "github.com/merkle3/merkle-sdk-go/proto/merkle/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// a local reference implementation of the merkle gRPC service, for tests.
+// Items published to it are fanned out to every open stream.
+type Server struct {
+	merklev1.UnimplementedMerkleServiceServer
+
+	// if set, streams must be opened with this api key
+	ApiKey string
+
+	// items buffered per stream before new ones are dropped, defaults to 1024
+	BufferSize int
+
+	// handle bids, optional
+	OnBid func(ctx context.Context, bid *merklev1.Bid) (string, error)
+
+	// handle simulations, optional
+	OnSimulate func(ctx context.Context, req *merklev1.SimulationRequest) (*merklev1.SimulationResponse, error)
+
+	mu           sync.Mutex
+	transactions map[chan *merklev1.Transaction]int64
+	auctions     map[chan *merklev1.Auction][]int64
+
+	dropped atomic.Uint64
+}
+
+func NewServer() *Server {
+	return &Server{
+		transactions: map[chan *merklev1.Transaction]int64{},
+		auctions:     map[chan *merklev1.Auction][]int64{},
+	}
+}
+
+// a grpc server serving the reference implementation, with keepalives enabled
+func (s *Server) GRPCServer(options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append([]grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    30 * time.Second,
+			Timeout: 10 * time.Second,
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             5 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}, options...)...)
+
+	merklev1.RegisterMerkleServiceServer(server, s)
+
+	return server
+}
+
+// number of items dropped because a stream was too slow
+func (s *Server) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Server) bufferSize() int {
+	if s.BufferSize <= 0 {
+		return 1024
+	}
+
+	return s.BufferSize
+}
+
+func (s *Server) authenticate(ctx context.Context) error {
+	if s.ApiKey == "" {
+		return nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	for _, value := range md.Get("authorization") {
+		if value == "Token "+s.ApiKey {
+			return nil
+		}
+	}
+
+	return status.Error(codes.Unauthenticated, "invalid api key")
+}
+
+// send a transaction to the streams of its chain
+func (s *Server) PublishTransaction(chainId merkle.MerkleChainId, tx *types.Transaction) error {
+	msg, err := transactionToProto(tx, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	s.publishTransaction(chainId, msg)
+
+	return nil
+}
+
+// send a binary encoded transaction to the streams of its chain, as is,
+// e.g. to test transaction types the sdk can't decode
+func (s *Server) PublishRawTransaction(chainId merkle.MerkleChainId, raw []byte) {
+	s.publishTransaction(chainId, &merklev1.Transaction{
+		Raw:            raw,
+		SeenAtUnixNano: time.Now().UnixNano(),
+	})
+}
+
+func (s *Server) publishTransaction(chainId merkle.MerkleChainId, msg *merklev1.Transaction) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream, streamChainId := range s.transactions {
+		if streamChainId != int64(chainId) {
+			continue
+		}
+
+		select {
+		case stream <- msg:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+// send an auction to the streams of its chain
+func (s *Server) PublishAuction(auction *merkle.Auction) {
+	msg := auctionToProto(auction)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream, chainIds := range s.auctions {
+		if !containsChain(chainIds, auction.ChainId) {
+			continue
+		}
+
+		select {
+		case stream <- msg:
+		default:
+			s.dropped.Add(1)
+		}
+	}
+}
+
+func containsChain(chainIds []int64, chainId int64) bool {
+	if len(chainIds) == 0 {
+		return true
+	}
+
+	for _, id := range chainIds {
+		if id == chainId {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (s *Server) StreamTransactions(req *merklev1.StreamTransactionsRequest, srv merklev1.MerkleService_StreamTransactionsServer) error {
+	if err := s.authenticate(srv.Context()); err != nil {
+		return err
+	}
+
+	stream := make(chan *merklev1.Transaction, s.bufferSize())
+
+	s.mu.Lock()
+	s.transactions[stream] = req.ChainId
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.transactions, stream)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-stream:
+			// blocks while the client's flow control window is full
+			if err := srv.Send(msg); err != nil {
+				return err
+			}
+		case <-srv.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) StreamAuctions(req *merklev1.StreamAuctionsRequest, srv merklev1.MerkleService_StreamAuctionsServer) error {
+	if err := s.authenticate(srv.Context()); err != nil {
+		return err
+	}
+
+	stream := make(chan *merklev1.Auction, s.bufferSize())
+
+	s.mu.Lock()
+	s.auctions[stream] = req.ChainIds
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.auctions, stream)
+		s.mu.Unlock()
+	}()
+
+	for {
+		select {
+		case msg := <-stream:
+			if err := srv.Send(msg); err != nil {
+				return err
+			}
+		case <-srv.Context().Done():
+			return nil
+		}
+	}
+}
+
+func (s *Server) SendBid(ctx context.Context, bid *merklev1.Bid) (*merklev1.SendBidResponse, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.OnBid == nil {
+		return nil, status.Error(codes.Unimplemented, "bids are not handled")
+	}
+
+	bidId, err := s.OnBid(ctx, bid)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &merklev1.SendBidResponse{BidId: bidId}, nil
+}
+
+func (s *Server) Simulate(ctx context.Context, req *merklev1.SimulationRequest) (*merklev1.SimulationResponse, error) {
+	if err := s.authenticate(ctx); err != nil {
+		return nil, err
+	}
+
+	if s.OnSimulate == nil {
+		return nil, status.Error(codes.Unimplemented, "simulations are not handled")
+	}
+
+	return s.OnSimulate(ctx, req)
+}
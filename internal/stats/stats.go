@@ -0,0 +1,35 @@
+// Package stats holds the statistics shared by the sdk packages.
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// a sorted copy of durations
+func Sorted(durations []time.Duration) []time.Duration {
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	return sorted
+}
+
+// nearest rank percentile of sorted durations, 0 if there are none
+func Percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
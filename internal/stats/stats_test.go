@@ -0,0 +1,50 @@
+package stats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	ten := []time.Duration{}
+
+	for i := 1; i <= 10; i++ {
+		ten = append(ten, time.Duration(i))
+	}
+
+	tests := []struct {
+		name   string
+		sorted []time.Duration
+		p      float64
+		want   time.Duration
+	}{
+		{"empty", nil, 50, 0},
+		{"single", []time.Duration{7}, 99, 7},
+		{"zero percentile is the minimum", ten, 0, 1},
+		{"median of an even count is the lower one", ten, 50, 5},
+		{"rank rounds up", ten, 51, 6},
+		{"p90", ten, 90, 9},
+		{"p99", ten, 99, 10},
+		{"p100 is the maximum", ten, 100, 10},
+		{"median of an odd count", []time.Duration{1, 2, 3}, 50, 2},
+	}
+
+	for _, test := range tests {
+		if got := Percentile(test.sorted, test.p); got != test.want {
+			t.Errorf("%s: got %d, want %d", test.name, got, test.want)
+		}
+	}
+}
+
+func TestSortedCopies(t *testing.T) {
+	durations := []time.Duration{3, 1, 2}
+	sorted := Sorted(durations)
+
+	if sorted[0] != 1 || sorted[1] != 2 || sorted[2] != 3 {
+		t.Fatalf("got %v", sorted)
+	}
+
+	if durations[0] != 3 {
+		t.Fatalf("the durations were sorted in place")
+	}
+}
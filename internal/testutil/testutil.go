@@ -0,0 +1,98 @@
+// Package testutil holds the helpers shared by the tests of the sdk packages.
+package testutil
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// how long the helpers wait for a subscription
+const Timeout = 5 * time.Second
+
+// the next item of a subscription, fails the test on an error or a timeout
+func Next[T any](t testing.TB, items <-chan T, errs <-chan error) T {
+	t.Helper()
+
+	select {
+	case item := <-items:
+		return item
+	case err := <-errs:
+		t.Fatalf("subscription error: %s", err)
+	case <-time.After(Timeout):
+		t.Fatalf("no item received")
+	}
+
+	var zero T
+
+	return zero
+}
+
+func dynamicFeeTx(nonce uint64, to *common.Address) *types.DynamicFeeTx {
+	return &types.DynamicFeeTx{
+		ChainID:   big.NewInt(1),
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       21000,
+		To:        to,
+		Value:     big.NewInt(1),
+	}
+}
+
+// an unsigned mainnet transaction, nil to is a contract creation
+func Transaction(nonce uint64, to *common.Address) *types.Transaction {
+	return types.NewTx(dynamicFeeTx(nonce, to))
+}
+
+// the same transaction signed with a key
+func SignedTransaction(t testing.TB, key *ecdsa.PrivateKey, nonce uint64, to *common.Address) *types.Transaction {
+	t.Helper()
+
+	tx, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(1)), dynamicFeeTx(nonce, to))
+
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	return tx
+}
+
+// sends the requests of the test to a local server, whatever their host
+type rewriteTransport struct {
+	target *url.URL
+	next   http.RoundTripper
+}
+
+func (r *rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = r.target.Scheme
+	req.URL.Host = r.target.Host
+
+	return r.next.RoundTrip(req)
+}
+
+// serve the requests to the merkle apis with a handler for the rest of the
+// test. Clients with their own transport aren't affected. Tests using it
+// can't run in parallel
+func Serve(t testing.TB, handler http.Handler) {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	target, _ := url.Parse(server.URL)
+
+	previous := http.DefaultTransport
+	http.DefaultTransport = &rewriteTransport{target: target, next: previous}
+
+	t.Cleanup(func() {
+		http.DefaultTransport = previous
+		server.Close()
+	})
+}
@@ -0,0 +1,240 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// the state of the connection of an auction subscription
+type ConnectionState string
+
+const (
+	ConnectionConnecting   ConnectionState = "connecting"
+	ConnectionConnected    ConnectionState = "connected"
+	ConnectionDisconnected ConnectionState = "disconnected"
+
+	// the subscription is closed, it won't reconnect
+	ConnectionClosed ConnectionState = "closed"
+)
+
+type ConnectionEvent struct {
+	State ConnectionState
+	Time  time.Time
+
+	// connection attempts since the last successful one
+	Attempt int
+
+	// why the connection was lost or couldn't be opened
+	Err error
+
+	// when disconnected, how long until the next attempt
+	RetryIn time.Duration
+}
+
+type AuctionsOptions struct {
+	// wait between reconnections, doubled after every failed
+	// attempt. Default to 500 milliseconds and 30 seconds
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+
+	// give up after this many failed attempts in a row, 0 to retry forever
+	MaxRetries int
+
+	// auctions kept while the consumer is slow, the oldest are dropped
+	// when it's full since they're the closest to closing. Defaults to 256
+	BufferSize int
+
+	// time kept between the deadline of an auction and its close, see
+	// Auction.Deadline. Defaults to DefaultBidMargin
+	BidMargin time.Duration
+}
+
+func (o *AuctionsOptions) withDefaults() AuctionsOptions {
+	opts := AuctionsOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 500 * time.Millisecond
+	}
+
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = 30 * time.Second
+	}
+
+	if opts.MaxBackoff < opts.MinBackoff {
+		opts.MaxBackoff = opts.MinBackoff
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 256
+	}
+
+	if opts.BidMargin <= 0 {
+		opts.BidMargin = DefaultBidMargin
+	}
+
+	return opts
+}
+
+// a subscription of auctions that reconnects when the connection is lost.
+// Err reports skipped messages and lost connections, the oldest errors are
+// dropped if they aren't read. Its channels are closed once it's closed, or
+// once it gives up reconnecting in which case the reason is sent on Err first
+type AuctionSubscription struct {
+	*Subscription[*Auction]
+
+	states  chan ConnectionEvent
+	dropped atomic.Uint64
+}
+
+// the connection state changes, closed with the subscription. Events
+// are dropped if the channel isn't read
+func (s *AuctionSubscription) States() <-chan ConnectionEvent {
+	return s.states
+}
+
+// auctions dropped because the consumer was too slow
+func (s *AuctionSubscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *AuctionSubscription) emit(event ConnectionEvent) {
+	event.Time = time.Now()
+
+	select {
+	case s.states <- event:
+	default:
+	}
+}
+
+// stream the auctions of the private pool through the sdk transport, until
+// the context is done or the subscription is closed
+func (p *PrivatePool) Auctions(ctx context.Context, options *AuctionsOptions) *AuctionSubscription {
+	opts := options.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := &AuctionSubscription{
+		Subscription: NewSubscription(make(chan *Auction), make(chan error, 16)),
+		states:       make(chan ConnectionEvent, 16),
+	}
+
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
+
+	go func() {
+		defer close(sub.states)
+		defer sub.end()
+
+		p.runAuctions(ctx, p.sdk.GetTransport(), sub, opts)
+
+		sub.emit(ConnectionEvent{State: ConnectionClosed})
+	}()
+
+	return sub
+}
+
+// connect, forward and reconnect until the context is done or the retries run out
+func (p *PrivatePool) runAuctions(ctx context.Context, transport Transport, sub *AuctionSubscription, opts AuctionsOptions) {
+	queue := []*Auction{}
+	backoff := opts.MinBackoff
+	attempt := 0
+
+	for {
+		attempt++
+
+		sub.emit(ConnectionEvent{State: ConnectionConnecting, Attempt: attempt})
+
+		conn, err := transport.Auctions(ctx, p.sdk.GetApiKey())
+
+		if err == nil {
+			sub.emit(ConnectionEvent{State: ConnectionConnected, Attempt: attempt})
+
+			attempt = 0
+			backoff = opts.MinBackoff
+
+			queue, err = p.forwardAuctions(ctx, sub, conn, queue, opts)
+			conn.Close()
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+
+		if opts.MaxRetries > 0 && attempt >= opts.MaxRetries {
+			sub.report(fmt.Errorf("failed to connect to auctions after %d attempts: %s", attempt, err))
+			return
+		}
+
+		sub.emit(ConnectionEvent{State: ConnectionDisconnected, Attempt: attempt, Err: err, RetryIn: backoff})
+
+		if !sleep(ctx, backoff) {
+			return
+		}
+
+		backoff *= 2
+
+		if backoff > opts.MaxBackoff {
+			backoff = opts.MaxBackoff
+		}
+	}
+}
+
+// forward the auctions of a connection until it ends, returns the auctions
+// still queued and the last error of the connection
+func (p *PrivatePool) forwardAuctions(ctx context.Context, sub *AuctionSubscription, conn *Subscription[*Auction], queue []*Auction, opts AuctionsOptions) ([]*Auction, error) {
+	var lastErr error
+
+	items := conn.Items()
+	errs := conn.Err()
+
+	for {
+		// only offer an auction to the consumer when one is queued
+		var out chan *Auction
+		var next *Auction
+
+		if len(queue) > 0 {
+			out = sub.items
+			next = queue[0]
+		}
+
+		select {
+		case auction, ok := <-items:
+			if !ok {
+				return queue, lastErr
+			}
+
+			// bids of the auction use the margin and are recorded in the metrics
+			auction.bidMargin = opts.BidMargin
+			auction.pool = p
+
+			if len(queue) >= opts.BufferSize {
+				queue = queue[1:]
+				sub.dropped.Add(1)
+			}
+
+			queue = append(queue, auction)
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			lastErr = err
+			sub.report(err)
+		case out <- next:
+			queue = queue[1:]
+		case <-conn.Done():
+			return queue, lastErr
+		case <-ctx.Done():
+			return queue, ctx.Err()
+		}
+	}
+}
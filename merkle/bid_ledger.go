@@ -0,0 +1,205 @@
+package merkle
+
+import (
+	"math/big"
+	"sync"
+	"time"
+)
+
+// a bid in the ledger, with its last known status
+type LedgerEntry struct {
+	Bid *PlacedBid
+
+	// nil until a status is known
+	Status *BidStatus
+}
+
+// the outcome of a bid, accepted until a status says otherwise
+func (e LedgerEntry) State() BidState {
+	if e.Status == nil {
+		return BidAccepted
+	}
+
+	return e.Status.State
+}
+
+// outcomes of the bids of a ledger
+type LedgerStats struct {
+	Bids int
+
+	// bids without a final state
+	Pending int
+
+	Won      int
+	Outbid   int
+	Rejected int
+
+	// sum of the amounts of the bids won
+	WonAmount *big.Int
+}
+
+// the share of settled bids that won, 0 when none is settled
+func (s LedgerStats) WinRate() float64 {
+	settled := s.Won + s.Outbid + s.Rejected
+
+	if settled == 0 {
+		return 0
+	}
+
+	return float64(s.Won) / float64(settled)
+}
+
+// a local record of our bids and their outcomes, by auction. Bids sent by a
+// BidBuilder with a ledger are recorded, statuses are applied with Update
+type BidLedger struct {
+	mu sync.Mutex
+
+	entries   map[string]*LedgerEntry
+	byAuction map[string][]string
+}
+
+func NewBidLedger() *BidLedger {
+	return &BidLedger{
+		entries:   map[string]*LedgerEntry{},
+		byAuction: map[string][]string{},
+	}
+}
+
+// add a bid to the ledger
+func (l *BidLedger) Record(bid *PlacedBid) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.entries[bid.Id]; ok {
+		return
+	}
+
+	l.entries[bid.Id] = &LedgerEntry{Bid: bid}
+
+	auctionId := ""
+
+	if bid.Auction != nil {
+		auctionId = bid.Auction.Id
+	}
+
+	l.byAuction[auctionId] = append(l.byAuction[auctionId], bid.Id)
+}
+
+// set the status of a bid, returns false if the bid isn't in the ledger
+func (l *BidLedger) Update(status *BidStatus) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[status.BidId]
+
+	if !ok {
+		return false
+	}
+
+	entry.Status = status
+
+	return true
+}
+
+// a bid by id
+func (l *BidLedger) Get(bidId string) (LedgerEntry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entry, ok := l.entries[bidId]
+
+	if !ok {
+		return LedgerEntry{}, false
+	}
+
+	return *entry, true
+}
+
+// the bids sent for an auction, in the order they were recorded
+func (l *BidLedger) Auction(auctionId string) []LedgerEntry {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	entries := []LedgerEntry{}
+
+	for _, bidId := range l.byAuction[auctionId] {
+		entries = append(entries, *l.entries[bidId])
+	}
+
+	return entries
+}
+
+// the ids of the bids without a final state, e.g. to watch them
+func (l *BidLedger) Pending() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	pending := []string{}
+
+	for bidId, entry := range l.entries {
+		if !entry.State().Final() {
+			pending = append(pending, bidId)
+		}
+	}
+
+	return pending
+}
+
+func (l *BidLedger) Stats() LedgerStats {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	stats := LedgerStats{
+		Bids:      len(l.entries),
+		WonAmount: new(big.Int),
+	}
+
+	for _, entry := range l.entries {
+		switch entry.State() {
+		case BidWon:
+			stats.Won++
+
+			if entry.Bid.Amount != nil {
+				stats.WonAmount.Add(stats.WonAmount, entry.Bid.Amount)
+			}
+		case BidOutbid:
+			stats.Outbid++
+		case BidRejected:
+			stats.Rejected++
+		default:
+			stats.Pending++
+		}
+	}
+
+	return stats
+}
+
+// forget the bids sent before a time, returns how many were removed
+func (l *BidLedger) Prune(before time.Time) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	removed := 0
+
+	for auctionId, bidIds := range l.byAuction {
+		kept := []string{}
+
+		for _, bidId := range bidIds {
+			if l.entries[bidId].Bid.SentAt.Before(before) {
+				delete(l.entries, bidId)
+				removed++
+				continue
+			}
+
+			kept = append(kept, bidId)
+		}
+
+		if len(kept) == 0 {
+			delete(l.byAuction, auctionId)
+		} else {
+			l.byAuction[auctionId] = kept
+		}
+	}
+
+	return removed
+}
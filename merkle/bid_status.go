@@ -0,0 +1,115 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// the outcome of a bid sent to the relay
+type BidState string
+
+const (
+	// the relay accepted the bid, the auction isn't settled yet
+	BidAccepted BidState = "accepted"
+
+	// another bid won the auction
+	BidOutbid BidState = "outbid"
+
+	// the bid won the auction and was included in a block
+	BidWon BidState = "won"
+
+	// the relay refused the bid, see the reason
+	BidRejected BidState = "rejected"
+)
+
+// whether the state won't change anymore
+func (s BidState) Final() bool {
+	return s == BidOutbid || s == BidWon || s == BidRejected
+}
+
+var (
+	// the relay doesn't know the bid (yet)
+	ErrBidNotFound = errors.New("bid not found")
+)
+
+type BidStatus struct {
+	BidId     string
+	AuctionId string
+	State     BidState
+	UpdatedAt time.Time
+
+	// where the bid landed, once won
+	BlockNumber uint64
+	Position    int
+
+	// why the bid was rejected
+	Reason string
+}
+
+type RawBidStatus struct {
+	Id            string `json:"id"`
+	AuctionId     string `json:"auction_id"`
+	Status        string `json:"status"`
+	UpdatedAtUnix int64  `json:"updated_at_unix"`
+	BlockNumber   uint64 `json:"block_number"`
+	Position      int    `json:"position"`
+	Reason        string `json:"reason"`
+}
+
+func (r *RawBidStatus) BidStatus() *BidStatus {
+	status := &BidStatus{
+		BidId:       r.Id,
+		AuctionId:   r.AuctionId,
+		State:       BidState(r.Status),
+		BlockNumber: r.BlockNumber,
+		Position:    r.Position,
+		Reason:      r.Reason,
+	}
+
+	if r.UpdatedAtUnix > 0 {
+		status.UpdatedAt = time.Unix(r.UpdatedAtUnix, 0)
+	}
+
+	return status
+}
+
+// the status of a bid, by the id returned by the relay
+func (p *PrivatePool) BidStatus(ctx context.Context, bidId string) (*BidStatus, error) {
+	raw := &RawBidStatus{}
+
+	err := p.poolRequest(ctx, "GET", fmt.Sprintf("%s/bids/%s/status", poolApiUrl, bidId), nil, raw)
+
+	if StatusCode(err) == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", bidId, ErrBidNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status := raw.BidStatus()
+	status.BidId = bidId
+
+	return status, nil
+}
+
+// watch the status of bids, a status is pushed every time it changes. A bid
+// isn't watched anymore once its state is final and the subscription is
+// closed once they all are. Unknown bids are retried
+func (p *PrivatePool) WatchBids(ctx context.Context, bidIds []string, options *WatchStatusOptions) *Subscription[*BidStatus] {
+	return watch(ctx, bidIds, options, watcher[string, *BidStatus]{
+		fetch:    p.BidStatus,
+		notFound: ErrBidNotFound,
+		changed: func(previous *BidStatus, status *BidStatus) bool {
+			return previous.State != status.State ||
+				previous.BlockNumber != status.BlockNumber ||
+				previous.Position != status.Position
+		},
+		final: func(status *BidStatus) bool {
+			return status.State.Final()
+		},
+	})
+}
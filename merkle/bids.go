@@ -0,0 +1,405 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// where the bid builder reads fees when an auction doesn't share the fees
+// of its transaction, e.g. an ethclient.Client
+type FeeSource interface {
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	SuggestGasPrice(ctx context.Context) (*big.Int, error)
+}
+
+var (
+	// the auction doesn't share the fees of its transaction and there's no fee source
+	ErrUnknownFees = errors.New("auction doesn't share its fees and no fee source is set")
+
+	// the bid pays the fee recipient of an auction that doesn't have one
+	ErrNoAuctionFeeRecipient = errors.New("auction has no fee recipient")
+)
+
+// how the bid amount is paid
+type BidPayment string
+
+const (
+	// the backrun pays the amount itself, e.g. with block.coinbase.transfer
+	PayInBackrun BidPayment = ""
+
+	// a transaction after the backrun pays the amount to the block builder
+	// with its priority fee, rounded up to a multiple of 21000 wei. On legacy
+	// transactions the builder also gets the premium of the target gas price
+	// over the base fee
+	PayCoinbase BidPayment = "coinbase"
+
+	// a transaction after the backrun transfers the amount to the fee
+	// recipient of the auction
+	PayFeeRecipient BidPayment = "fee_recipient"
+)
+
+// gas of a plain transfer, used by payment transactions
+const transferGas = 21000
+
+// the backrun of a bid
+type Backrun struct {
+	// the contract called by the backrun and its calldata
+	To   common.Address
+	Data []byte
+
+	// optional, the value sent with the backrun
+	Value *big.Int
+
+	// gas limit of the backrun, defaults to BidBuilderOptions.Gas
+	Gas uint64
+
+	// the bid, paid as set by Payment
+	Amount  *big.Int
+	Payment BidPayment
+}
+
+type BidBuilderOptions struct {
+	// where the nonce of the signer is read, required
+	// (ethclient.Client implements it)
+	Nonces NonceSource
+
+	// where the fees are read when an auction doesn't share them, optional
+	Fees FeeSource
+
+	// the default gas limit of backruns, defaults to 300000
+	Gas uint64
+
+	// how long a nonce read from the chain is reused, defaults to 1 second
+	NonceRefresh time.Duration
+
+	// where the bids sent are recorded, optional
+	Ledger *BidLedger
+}
+
+func (o *BidBuilderOptions) withDefaults() BidBuilderOptions {
+	opts := BidBuilderOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.Gas == 0 {
+		opts.Gas = 300000
+	}
+
+	if opts.NonceRefresh <= 0 {
+		opts.NonceRefresh = 1 * time.Second
+	}
+
+	return opts
+}
+
+// a bid sent to the relay
+type PlacedBid struct {
+	Id      string
+	Auction *Auction
+
+	// the backrun, followed by the payment if any
+	Transactions []*types.Transaction
+
+	Amount *big.Int
+	SentAt time.Time
+}
+
+// builds, signs and sends backrun bids for auctions. The nonce of the signer
+// is read from the chain and reused across bids, since only the bids that win
+// use it. Fees follow the target transaction so the backrun is valid in the
+// same block
+type BidBuilder struct {
+	signer Signer
+	opts   BidBuilderOptions
+
+	mu        sync.Mutex
+	nonce     uint64
+	fetchedAt time.Time
+}
+
+func NewBidBuilder(signer Signer, options *BidBuilderOptions) (*BidBuilder, error) {
+	opts := options.withDefaults()
+
+	if signer == nil {
+		return nil, fmt.Errorf("a signer is required")
+	}
+
+	if opts.Nonces == nil {
+		return nil, fmt.Errorf("a nonce source is required")
+	}
+
+	return &BidBuilder{
+		signer: signer,
+		opts:   opts,
+	}, nil
+}
+
+// read the nonce again for the next bid, e.g. after a bid won
+func (b *BidBuilder) ResetNonce() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.fetchedAt = time.Time{}
+}
+
+func (b *BidBuilder) nextNonce(ctx context.Context) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.fetchedAt.IsZero() && time.Since(b.fetchedAt) < b.opts.NonceRefresh {
+		return b.nonce, nil
+	}
+
+	nonce, err := b.opts.Nonces.NonceAt(ctx, b.signer.Address(), nil)
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get nonce: %s", err)
+	}
+
+	b.nonce = nonce
+	b.fetchedAt = time.Now()
+
+	return nonce, nil
+}
+
+// the fees of a bid, gasPrice is set for legacy transactions
+type bidFees struct {
+	gasPrice  *big.Int
+	gasFeeCap *big.Int
+	gasTipCap *big.Int
+}
+
+// the fees of the target transaction, or the current ones if it doesn't share them
+func (b *BidBuilder) fees(ctx context.Context, auction *Auction) (*bidFees, error) {
+	if tx := auction.Transaction; tx != nil {
+		if tx.GasFeeCap != nil && tx.GasTipCap != nil {
+			return &bidFees{gasFeeCap: tx.GasFeeCap, gasTipCap: tx.GasTipCap}, nil
+		}
+
+		if tx.GasPrice != nil {
+			return &bidFees{gasPrice: tx.GasPrice}, nil
+		}
+	}
+
+	if b.opts.Fees == nil {
+		return nil, ErrUnknownFees
+	}
+
+	header, err := b.opts.Fees.HeaderByNumber(ctx, nil)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get header: %s", err)
+	}
+
+	if header.BaseFee == nil {
+		gasPrice, err := b.opts.Fees.SuggestGasPrice(ctx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get gas price: %s", err)
+		}
+
+		return &bidFees{gasPrice: gasPrice}, nil
+	}
+
+	tip, err := b.opts.Fees.SuggestGasTipCap(ctx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas tip cap: %s", err)
+	}
+
+	// room for the base fee to double
+	feeCap := new(big.Int).Mul(header.BaseFee, big.NewInt(2))
+	feeCap.Add(feeCap, tip)
+
+	return &bidFees{gasFeeCap: feeCap, gasTipCap: tip}, nil
+}
+
+// a transaction paying the fees
+func (f *bidFees) transaction(chainId *big.Int, nonce uint64, to common.Address, value *big.Int, gas uint64, data []byte) *types.Transaction {
+	if f.gasPrice != nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: f.gasPrice,
+			Gas:      gas,
+			To:       &to,
+			Value:    value,
+			Data:     data,
+		})
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: f.gasTipCap,
+		GasFeeCap: f.gasFeeCap,
+		Gas:       gas,
+		To:        &to,
+		Value:     value,
+		Data:      data,
+	})
+}
+
+// a transfer to self using all its gas, whose priority fee pays the amount to
+// the block builder. The fee cap of the fees covers the base fee of the block
+// of the target, the tip is added on top of it
+func (f *bidFees) coinbasePayment(chainId *big.Int, nonce uint64, to common.Address, amount *big.Int) *types.Transaction {
+	// rounded up so the builder gets at least the amount
+	tip := new(big.Int).Add(amount, big.NewInt(transferGas-1))
+	tip.Div(tip, big.NewInt(transferGas))
+
+	if f.gasPrice != nil {
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    nonce,
+			GasPrice: new(big.Int).Add(f.gasPrice, tip),
+			Gas:      transferGas,
+			To:       &to,
+			Value:    new(big.Int),
+		})
+	}
+
+	return types.NewTx(&types.DynamicFeeTx{
+		ChainID:   chainId,
+		Nonce:     nonce,
+		GasTipCap: tip,
+		GasFeeCap: new(big.Int).Add(f.gasFeeCap, tip),
+		Gas:       transferGas,
+		To:        &to,
+		Value:     new(big.Int),
+	})
+}
+
+// build and sign the transactions of a bid: the backrun, followed by the
+// payment if any
+func (b *BidBuilder) Build(ctx context.Context, auction *Auction, backrun *Backrun) ([]*types.Transaction, error) {
+	chainId := big.NewInt(auction.ChainId)
+
+	amount := backrun.Amount
+
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	var payTo common.Address
+
+	switch backrun.Payment {
+	case PayInBackrun:
+	case PayCoinbase:
+		payTo = b.signer.Address()
+	case PayFeeRecipient:
+		if !common.IsHexAddress(auction.FeeRecipient) {
+			return nil, fmt.Errorf("%s: %w", auction.Id, ErrNoAuctionFeeRecipient)
+		}
+
+		payTo = common.HexToAddress(auction.FeeRecipient)
+	default:
+		return nil, fmt.Errorf("unknown bid payment: %s", backrun.Payment)
+	}
+
+	fees, err := b.fees(ctx, auction)
+
+	if err != nil {
+		return nil, err
+	}
+
+	nonce, err := b.nextNonce(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	value := backrun.Value
+
+	if value == nil {
+		value = new(big.Int)
+	}
+
+	gas := backrun.Gas
+
+	if gas == 0 {
+		gas = b.opts.Gas
+	}
+
+	txs := []*types.Transaction{
+		fees.transaction(chainId, nonce, backrun.To, value, gas, backrun.Data),
+	}
+
+	switch backrun.Payment {
+	case PayCoinbase:
+		txs = append(txs, fees.coinbasePayment(chainId, nonce+1, payTo, amount))
+	case PayFeeRecipient:
+		txs = append(txs, fees.transaction(chainId, nonce+1, payTo, amount, transferGas, nil))
+	}
+
+	for i, tx := range txs {
+		signed, err := b.signer.SignTx(chainId, tx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign bid transaction: %s", err)
+		}
+
+		txs[i] = signed
+	}
+
+	return txs, nil
+}
+
+// build, sign and send a bid for an auction. The bid is built under the
+// deadline of the auction, ErrAuctionClosed is returned when it passes
+func (b *BidBuilder) Send(ctx context.Context, auction *Auction, backrun *Backrun) (*PlacedBid, error) {
+	buildCtx, cancel := auction.Context(ctx)
+	defer cancel()
+
+	txs, err := b.Build(buildCtx, auction, backrun)
+
+	if err != nil {
+		if ctx.Err() == nil && buildCtx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("bid for auction %s: %s: %w", auction.Id, err, ErrAuctionClosed)
+		}
+
+		return nil, err
+	}
+
+	raw := []string{}
+
+	for _, tx := range txs {
+		bin, err := tx.MarshalBinary()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal transaction: %s", err)
+		}
+
+		raw = append(raw, common.Bytes2Hex(bin))
+	}
+
+	sentAt := time.Now()
+
+	bidId, err := auction.SendRawBid(ctx, raw)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bid := &PlacedBid{
+		Id:           bidId,
+		Auction:      auction,
+		Transactions: txs,
+		Amount:       backrun.Amount,
+		SentAt:       sentAt,
+	}
+
+	if b.opts.Ledger != nil {
+		b.opts.Ledger.Record(bid)
+	}
+
+	return bid, nil
+}
@@ -0,0 +1,140 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// errors of bundles rejected before they're sent, match them with errors.Is
+var (
+	ErrEmptyBundle        = errors.New("bundle has no transactions")
+	ErrMixedChainIds      = errors.New("bundle transactions are for different chains")
+	ErrNonceGap           = errors.New("bundle nonces of a sender aren't consecutive")
+	ErrNoFeeRecipient     = errors.New("bundle has several senders and no fee recipient")
+	ErrDuplicateBundleTxs = errors.New("bundle contains the same transaction twice")
+)
+
+type NewBundleOptions struct {
+	// the transactions, in the order they must be executed. The transactions
+	// of a sender must have consecutive nonces
+	Transactions []*types.Transaction
+
+	// defaults to the sender of the transactions, required if there are several
+	FeeRecipient common.Address
+
+	// optionally, a source
+	Source string
+
+	// prevent reverts
+	PreventRevert bool
+
+	// the fields shared with searchers, override the privacy profile
+	Hints []Hint
+
+	// a named set of hints
+	PrivacyProfile PrivacyProfile
+
+	// the bundles searchers may build around the transactions, all if empty
+	BundleTypes []BundleType
+
+	// where the transactions may be released, all if empty
+	ReleaseTargets []ReleaseTarget
+}
+
+// send an ordered group of transactions to the private pool, they're
+// handled atomically. The bundle is validated before it's sent
+func (p *PrivatePool) SendBundle(ctx context.Context, options *NewBundleOptions) (*SubmissionReceipt, error) {
+	senders, err := validateBundle(options.Transactions)
+
+	if err != nil {
+		return nil, err
+	}
+
+	feeRecipient := options.FeeRecipient
+
+	if feeRecipient == (common.Address{}) {
+		if len(senders) > 1 {
+			return nil, ErrNoFeeRecipient
+		}
+
+		feeRecipient = senders[0]
+	}
+
+	submission := &poolSubmission{
+		Transactions:   []string{},
+		FeeRecipient:   feeRecipient.String(),
+		Source:         options.Source,
+		Privacy:        options.PrivacyProfile,
+		Hints:          options.Hints,
+		PreventReverts: options.PreventRevert,
+	}
+
+	privacy := PrivacySettings{Profile: options.PrivacyProfile, Hints: options.Hints}
+
+	if err := privacy.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := submission.setTargets(options.BundleTypes, options.ReleaseTargets); err != nil {
+		return nil, err
+	}
+
+	for _, tx := range options.Transactions {
+		txBytes, err := tx.MarshalBinary()
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal transaction: %s", err)
+		}
+
+		submission.Transactions = append(submission.Transactions, common.Bytes2Hex(txBytes))
+	}
+
+	return p.submit(ctx, submission)
+}
+
+// check the chain ids and nonces of a bundle, returns its senders in order
+func validateBundle(txs []*types.Transaction) ([]common.Address, error) {
+	if len(txs) == 0 {
+		return nil, ErrEmptyBundle
+	}
+
+	chainId := txs[0].ChainId()
+
+	senders := []common.Address{}
+	nonces := map[common.Address]uint64{}
+	hashes := map[common.Hash]bool{}
+
+	for i, tx := range txs {
+		if tx.ChainId().Cmp(chainId) != 0 {
+			return nil, fmt.Errorf("transaction %d is for chain %s, not %s: %w", i, tx.ChainId().String(), chainId.String(), ErrMixedChainIds)
+		}
+
+		if hashes[tx.Hash()] {
+			return nil, fmt.Errorf("transaction %d: %w", i, ErrDuplicateBundleTxs)
+		}
+
+		hashes[tx.Hash()] = true
+
+		from, err := types.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sender of transaction %d: %s", i, err)
+		}
+
+		last, ok := nonces[from]
+
+		if !ok {
+			senders = append(senders, from)
+		} else if tx.Nonce() != last+1 {
+			return nil, fmt.Errorf("transaction %d of %s has nonce %d, expected %d: %w", i, from.String(), tx.Nonce(), last+1, ErrNonceGap)
+		}
+
+		nonces[from] = tx.Nonce()
+	}
+
+	return senders, nil
+}
@@ -0,0 +1,92 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+var (
+	// the transaction can't be cancelled anymore, it's in a block
+	ErrAlreadyIncluded = errors.New("transaction already included")
+
+	// the transaction can't be cancelled anymore, it was sent to builders
+	ErrAlreadyReleased = errors.New("transaction already released")
+)
+
+type CancelOptions struct {
+	// sign the cancellation with the key of the sender, optional,
+	// without it the api key authenticates the cancellation
+	Signer Signer
+}
+
+type cancelRequest struct {
+	Hash      string `json:"hash"`
+	Signer    string `json:"signer,omitempty"`
+	Signature string `json:"signature,omitempty"`
+}
+
+type cancelResponse struct {
+	Cancelled bool `json:"cancelled"`
+}
+
+// the message signed by the sender to cancel a transaction
+func cancelMessage(hash common.Hash) []byte {
+	return []byte("cancel:" + hash.String())
+}
+
+// withdraw a transaction sent to the private pool, returns true if it was cancelled
+// before being released. A transaction that can't be cancelled anymore returns
+// ErrAlreadyIncluded or ErrAlreadyReleased
+func (p *PrivatePool) Cancel(ctx context.Context, hash common.Hash, options *CancelOptions) (bool, error) {
+	body := &cancelRequest{
+		Hash: hash.String(),
+	}
+
+	if options != nil && options.Signer != nil {
+		signature, err := options.Signer.SignMessage(cancelMessage(hash))
+
+		if err != nil {
+			return false, fmt.Errorf("failed to sign cancellation: %s", err)
+		}
+
+		body.Signer = options.Signer.Address().String()
+		body.Signature = hexutil.Encode(signature)
+	} else if p.sdk.GetApiKey() == "" {
+		return false, fmt.Errorf("an api key or a signer is required to cancel a transaction")
+	}
+
+	res := &cancelResponse{}
+
+	err := p.poolRequest(ctx, "POST", fmt.Sprintf("%s/transactions/%s/cancel", poolApiUrl, hash.String()), body, res)
+
+	apiErr := &APIError{}
+
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusNotFound:
+			return false, fmt.Errorf("%s: %w", hash.String(), ErrPoolTransactionNotFound)
+		case http.StatusConflict:
+			reason := strings.ToLower(errorReason(apiErr.Body))
+
+			if strings.Contains(reason, "included") {
+				return false, fmt.Errorf("failed to cancel %s: %w: %w", hash.String(), ErrAlreadyIncluded, apiErr)
+			}
+
+			if strings.Contains(reason, "released") {
+				return false, fmt.Errorf("failed to cancel %s: %w: %w", hash.String(), ErrAlreadyReleased, apiErr)
+			}
+		}
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return res.Cancelled, nil
+}
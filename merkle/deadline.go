@@ -0,0 +1,191 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/merkle3/merkle-sdk-go/internal/stats"
+)
+
+// the default time kept between the last bid and the close of an auction,
+// to leave the relay time to receive it
+const DefaultBidMargin = 100 * time.Millisecond
+
+// the bid was too late for its auction
+var ErrAuctionClosed = errors.New("auction closed")
+
+func (a *Auction) margin() time.Duration {
+	if a.bidMargin > 0 {
+		return a.bidMargin
+	}
+
+	return DefaultBidMargin
+}
+
+// the last moment to send a bid, the close of the auction minus the bid margin
+func (a *Auction) Deadline() time.Time {
+	return a.ClosesAt.Add(-a.margin())
+}
+
+// a context cancelled at the deadline of the auction
+func (a *Auction) Context(parent context.Context) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(parent, a.Deadline())
+}
+
+// send a bid of hex encoded transactions for the auction, returns the bid
+// id. The bid is rejected with ErrAuctionClosed when it's sent after the
+// deadline of the auction, or the deadline passes while it's sent
+func (a *Auction) SendRawBid(ctx context.Context, txs []string) (string, error) {
+	sentAt := time.Now()
+	timeToClose := a.ClosesAt.Sub(sentAt)
+
+	if !sentAt.Before(a.Deadline()) {
+		a.record(BidTiming{AuctionId: a.Id, SentAt: sentAt, TimeToClose: timeToClose, Late: true})
+
+		return "", fmt.Errorf("bid for auction %s is %s late: %w", a.Id, sentAt.Sub(a.Deadline()), ErrAuctionClosed)
+	}
+
+	bidCtx, cancel := a.Context(ctx)
+	defer cancel()
+
+	bidId, err := sendRawBid(bidCtx, a.Transaction.Hash.String(), txs)
+
+	// the auction closed during the request, not the parent context
+	if err != nil && ctx.Err() == nil && bidCtx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("bid for auction %s: %w", a.Id, ErrAuctionClosed)
+	}
+
+	a.record(BidTiming{
+		AuctionId:   a.Id,
+		SentAt:      sentAt,
+		TimeToClose: timeToClose,
+		Latency:     time.Since(sentAt),
+		Err:         err,
+	})
+
+	return bidId, err
+}
+
+func (a *Auction) record(timing BidTiming) {
+	if a.pool != nil {
+		a.pool.bids.record(timing)
+	}
+}
+
+// the timing of a bid sent for an auction
+type BidTiming struct {
+	AuctionId string
+	SentAt    time.Time
+
+	// left before the auction closed when the bid was sent, negative if it
+	// was sent after the close
+	TimeToClose time.Duration
+
+	// round trip to the relay
+	Latency time.Duration
+
+	// rejected locally because the deadline had passed
+	Late bool
+
+	Err error
+}
+
+// how close to the deadline of their auctions bids are sent, for the
+// auctions streamed by the pool. Durations cover the recent bids
+type BidMetrics struct {
+	// bids sent to the relay, including failed ones
+	Sent uint64
+
+	// bids rejected locally because the deadline had passed
+	Late uint64
+
+	// bids the relay failed or refused
+	Failed uint64
+
+	// left before the auctions closed when the bids were sent
+	MinTimeToClose time.Duration
+	P10TimeToClose time.Duration
+	P50TimeToClose time.Duration
+
+	// round trip of the bids to the relay
+	P50Latency time.Duration
+	P90Latency time.Duration
+}
+
+// number of bids kept for the metrics durations
+const bidWindow = 1024
+
+type bidRecorder struct {
+	mu sync.Mutex
+
+	sent   uint64
+	late   uint64
+	failed uint64
+
+	// ring buffers of the recent bids
+	timeToClose []time.Duration
+	latency     []time.Duration
+	next        int
+}
+
+func newBidRecorder() *bidRecorder {
+	return &bidRecorder{}
+}
+
+func (r *bidRecorder) record(timing BidTiming) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if timing.Late {
+		r.late++
+		return
+	}
+
+	r.sent++
+
+	if timing.Err != nil {
+		r.failed++
+	}
+
+	if len(r.timeToClose) < bidWindow {
+		r.timeToClose = append(r.timeToClose, timing.TimeToClose)
+		r.latency = append(r.latency, timing.Latency)
+		return
+	}
+
+	r.timeToClose[r.next] = timing.TimeToClose
+	r.latency[r.next] = timing.Latency
+	r.next = (r.next + 1) % bidWindow
+}
+
+func (r *bidRecorder) metrics() BidMetrics {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timeToClose := stats.Sorted(r.timeToClose)
+	latency := stats.Sorted(r.latency)
+
+	metrics := BidMetrics{
+		Sent:           r.sent,
+		Late:           r.late,
+		Failed:         r.failed,
+		P10TimeToClose: stats.Percentile(timeToClose, 10),
+		P50TimeToClose: stats.Percentile(timeToClose, 50),
+		P50Latency:     stats.Percentile(latency, 50),
+		P90Latency:     stats.Percentile(latency, 90),
+	}
+
+	if len(timeToClose) > 0 {
+		metrics.MinTimeToClose = timeToClose[0]
+	}
+
+	return metrics
+}
+
+// the timing metrics of the bids sent for the auctions of the pool
+func (p *PrivatePool) BidMetrics() BidMetrics {
+	return p.bids.metrics()
+}
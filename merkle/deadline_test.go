@@ -0,0 +1,60 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+)
+
+func TestSendRawBid(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		bidId  string
+		check  func(err error) bool
+	}{
+		{"accepted", http.StatusOK, `{"jsonrpc":"2.0","id":1,"result":"bid"}`, "bid", nil},
+		{"bad request", http.StatusBadRequest, `{"error":"invalid bundle"}`, "", func(err error) bool {
+			return StatusCode(err) == http.StatusBadRequest
+		}},
+		{"refused", http.StatusOK, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"auction not found"}}`, "", func(err error) bool {
+			rpcErr := &RPCError{}
+			return errors.As(err, &rpcErr) && rpcErr.Message == "auction not found"
+		}},
+		{"no bid id", http.StatusOK, `{"jsonrpc":"2.0","id":1,"result":null}`, "", func(err error) bool {
+			return err != nil
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/relay" {
+					t.Errorf("bid sent to %s", r.URL.Path)
+				}
+
+				w.WriteHeader(test.status)
+				fmt.Fprint(w, test.body)
+			}))
+
+			bidId, err := sendRawBid(context.Background(), "0x01", []string{"02"})
+
+			if test.check == nil && err != nil {
+				t.Fatalf("failed to bid: %s", err)
+			}
+
+			if test.check != nil && !test.check(err) {
+				t.Fatalf("got error %v", err)
+			}
+
+			if bidId != test.bidId {
+				t.Fatalf("got bid id %q, want %q", bidId, test.bidId)
+			}
+		})
+	}
+}
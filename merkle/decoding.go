@@ -0,0 +1,175 @@
+package merkle
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// a transaction of the stream that couldn't be decoded, e.g. a
+// transaction type newer than the go-ethereum version of the sdk
+type UnknownTransaction struct {
+	// the envelope type, 0 for legacy transactions
+	Type byte
+
+	// the full binary encoding of the transaction
+	Raw []byte
+
+	// keccak256 of the encoding, the transaction hash
+	Hash common.Hash
+}
+
+// an item of the transaction stream, exactly one of the fields is set
+type StreamItem struct {
+	// a transaction go-ethereum could decode
+	Transaction *types.Transaction
+
+	// the value returned by a decoder registered with RegisterDecoder
+	Decoded interface{}
+
+	// a transaction nothing could decode
+	Unknown *UnknownTransaction
+}
+
+// the hash of the item
+func (i *StreamItem) Hash() common.Hash {
+	switch {
+	case i.Transaction != nil:
+		return i.Transaction.Hash()
+	case i.Unknown != nil:
+		return i.Unknown.Hash
+	default:
+		return common.Hash{}
+	}
+}
+
+// decodes transactions of a type, gets the full binary encoding.
+// Returning a *types.Transaction sets StreamItem.Transaction
+type TransactionDecoder func(raw []byte) (interface{}, error)
+
+// decode the transactions of a type with a custom decoder, it's tried
+// before go-ethereum, a nil decoder removes it
+func (t *TransactionStream) RegisterDecoder(txType byte, decoder TransactionDecoder) {
+	t.decodersMu.Lock()
+	defer t.decodersMu.Unlock()
+
+	if t.decoders == nil {
+		t.decoders = map[byte]TransactionDecoder{}
+	}
+
+	if decoder == nil {
+		delete(t.decoders, txType)
+		return
+	}
+
+	t.decoders[txType] = decoder
+}
+
+func (t *TransactionStream) decoder(txType byte) TransactionDecoder {
+	t.decodersMu.RLock()
+	defer t.decodersMu.RUnlock()
+
+	return t.decoders[txType]
+}
+
+// the envelope type of a binary transaction
+func envelopeType(raw []byte) byte {
+	// legacy transactions are rlp lists, starting at 0xc0
+	if len(raw) == 0 || raw[0] >= 0x80 {
+		return types.LegacyTxType
+	}
+
+	return raw[0]
+}
+
+func (t *TransactionStream) decode(raw []byte) *StreamItem {
+	txType := envelopeType(raw)
+
+	if decoder := t.decoder(txType); decoder != nil {
+		decoded, err := decoder(raw)
+
+		if err == nil {
+			t.stats.decodedByHook.Add(1)
+
+			if tx, ok := decoded.(*types.Transaction); ok {
+				return &StreamItem{Transaction: tx}
+			}
+
+			return &StreamItem{Decoded: decoded}
+		}
+	}
+
+	tx := new(types.Transaction)
+
+	if err := tx.UnmarshalBinary(raw); err == nil {
+		t.stats.decoded.Add(1)
+		return &StreamItem{Transaction: tx}
+	}
+
+	t.stats.countUnknown(txType)
+
+	return &StreamItem{
+		Unknown: &UnknownTransaction{
+			Type: txType,
+			Raw:  raw,
+			Hash: crypto.Keccak256Hash(raw),
+		},
+	}
+}
+
+type streamCounters struct {
+	decoded       atomic.Uint64
+	decodedByHook atomic.Uint64
+	dropped       atomic.Uint64
+
+	mu      sync.Mutex
+	unknown map[byte]uint64
+}
+
+func (c *streamCounters) countUnknown(txType byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.unknown == nil {
+		c.unknown = map[byte]uint64{}
+	}
+
+	c.unknown[txType]++
+}
+
+type StreamStats struct {
+	// transactions decoded by go-ethereum
+	Decoded uint64
+
+	// transactions decoded by a registered decoder
+	DecodedByHook uint64
+
+	// transactions nothing could decode, by envelope type
+	Unknown map[byte]uint64
+
+	// transactions not delivered by Stream and Subscribe because
+	// they couldn't be decoded into a types.Transaction
+	Dropped uint64
+}
+
+// counters of the transactions streamed so far, across subscriptions
+func (t *TransactionStream) Stats() StreamStats {
+	t.stats.mu.Lock()
+	unknown := make(map[byte]uint64, len(t.stats.unknown))
+
+	for txType, count := range t.stats.unknown {
+		unknown[txType] = count
+	}
+
+	t.stats.mu.Unlock()
+
+	return StreamStats{
+		Decoded:       t.stats.decoded.Load(),
+		DecodedByHook: t.stats.decodedByHook.Load(),
+		Unknown:       unknown,
+		Dropped:       t.stats.dropped.Load(),
+	}
+}
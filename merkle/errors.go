@@ -0,0 +1,85 @@
+package merkle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var (
+	// the transaction hasn't been seen by merkle (yet)
+	ErrTraceNotFound = errors.New("trace not found")
+)
+
+// an error response of a merkle api
+type APIError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("error sending request: url=%s code=%s, body=%s", e.URL, e.Status, e.Body)
+}
+
+// the status code of an api error, 0 if err isn't one
+func StatusCode(err error) int {
+	var apiErr *APIError
+
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+
+	return 0
+}
+
+// errors of the ethereum nodes, match them with errors.Is
+var (
+	ErrNonceTooLow       = errors.New("nonce too low")
+	ErrNonceTooHigh      = errors.New("nonce too high")
+	ErrUnderpriced       = errors.New("transaction underpriced")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAlreadyKnown      = errors.New("already known")
+	ErrIntrinsicGas      = errors.New("intrinsic gas too low")
+)
+
+// node error messages, checked in order
+var rpcErrorKinds = []struct {
+	message string
+	kind    error
+}{
+	{"nonce too low", ErrNonceTooLow},
+	{"nonce too high", ErrNonceTooHigh},
+	{"underpriced", ErrUnderpriced},
+	{"insufficient funds", ErrInsufficientFunds},
+	{"already known", ErrAlreadyKnown},
+	{"known transaction", ErrAlreadyKnown},
+	{"already imported", ErrAlreadyKnown},
+	{"intrinsic gas too low", ErrIntrinsicGas},
+}
+
+// a json-rpc error response
+type RPCError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error: code=%d, message=%s", e.Code, e.Message)
+}
+
+// the typed error matching the message, e.g. ErrNonceTooLow
+func (e *RPCError) Unwrap() error {
+	message := strings.ToLower(e.Message)
+
+	for _, kind := range rpcErrorKinds {
+		if strings.Contains(message, kind.message) {
+			return kind.kind
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,383 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// the default maximum size of an auction message
+const DefaultMaxMessageSize = 4 << 20
+
+var (
+	ErrMessageTooLarge = errors.New("message too large")
+	ErrMalformedFrame  = errors.New("malformed frame")
+)
+
+// what a json scanner accepts next
+const (
+	// the start of a message
+	expectMessage = iota
+	expectValue
+	expectValueOrClose
+	expectKey
+	expectKeyOrClose
+	expectColon
+	expectCommaOrClose
+)
+
+// checks the syntax of json messages byte by byte. Numbers and literals are
+// only checked for their characters
+type jsonScanner struct {
+	// the open objects and arrays
+	stack []byte
+	next  int
+
+	inString bool
+	isKey    bool
+	escaped  bool
+	inScalar bool
+}
+
+// whether the scanner is between messages
+func (s *jsonScanner) idle() bool {
+	return len(s.stack) == 0
+}
+
+func isScalar(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c == '-' || c == '+' || c == '.' || c == 'E'
+}
+
+// scan a byte, returns whether it completed a message and false if it's a
+// syntax error
+func (s *jsonScanner) step(c byte) (bool, bool) {
+	if s.inString {
+		switch {
+		case s.escaped:
+			s.escaped = false
+		case c == '\\':
+			s.escaped = true
+		case c == '"':
+			s.inString = false
+
+			if s.isKey {
+				s.next = expectColon
+			} else {
+				s.next = expectCommaOrClose
+			}
+		}
+
+		return false, true
+	}
+
+	if s.inScalar {
+		if isScalar(c) {
+			return false, true
+		}
+
+		s.inScalar = false
+		s.next = expectCommaOrClose
+	}
+
+	if isSpace(c) {
+		return false, true
+	}
+
+	switch s.next {
+	case expectMessage:
+		if c != '{' {
+			return false, false
+		}
+
+		s.stack = append(s.stack, c)
+		s.next = expectKeyOrClose
+	case expectValue, expectValueOrClose:
+		switch {
+		case c == ']' && s.next == expectValueOrClose:
+			return s.close(c)
+		case c == '{':
+			s.stack = append(s.stack, c)
+			s.next = expectKeyOrClose
+		case c == '[':
+			s.stack = append(s.stack, c)
+			s.next = expectValueOrClose
+		case c == '"':
+			s.inString = true
+			s.isKey = false
+		case isScalar(c):
+			s.inScalar = true
+		default:
+			return false, false
+		}
+	case expectKey, expectKeyOrClose:
+		switch {
+		case c == '}' && s.next == expectKeyOrClose:
+			return s.close(c)
+		case c == '"':
+			s.inString = true
+			s.isKey = true
+		default:
+			return false, false
+		}
+	case expectColon:
+		if c != ':' {
+			return false, false
+		}
+
+		s.next = expectValue
+	case expectCommaOrClose:
+		switch {
+		case c == ',' && s.stack[len(s.stack)-1] == '{':
+			s.next = expectKey
+		case c == ',':
+			s.next = expectValue
+		case c == '}' || c == ']':
+			return s.close(c)
+		default:
+			return false, false
+		}
+	}
+
+	return false, true
+}
+
+// close the innermost object or array
+func (s *jsonScanner) close(c byte) (bool, bool) {
+	open := s.stack[len(s.stack)-1]
+
+	if open == '{' && c != '}' || open == '[' && c != ']' {
+		return false, false
+	}
+
+	s.stack = s.stack[:len(s.stack)-1]
+
+	if s.idle() {
+		s.next = expectMessage
+		return true, true
+	}
+
+	s.next = expectCommaOrClose
+
+	return false, true
+}
+
+// a guess that a message started at a frame boundary while the previous one
+// was still pending, in case the previous one was cut short. It's scanned
+// along with the pending message and may replace it if it turns out to be
+// malformed
+type resync struct {
+	scanner jsonScanner
+
+	// offsets in the buffer of the message being scanned, and of the
+	// messages already completed
+	start     int
+	completed [][2]int
+}
+
+// where the resync starts in the buffer
+func (r *resync) first() int {
+	if len(r.completed) > 0 {
+		return r.completed[0][0]
+	}
+
+	return r.start
+}
+
+// the most frames a pending message is resynced from
+const maxResyncs = 16
+
+// reassembles json messages split across websocket frames. It scans the
+// messages as frames arrive, so every byte is looked at once. After a bad
+// frame, frames are dropped until one starts a new message. A message cut
+// short is dropped when the messages after it show it's malformed, provided
+// a frame starts with the next message. The next message may only be
+// delivered once the one after it is complete, and an object of the message
+// cut short starting a frame can't be told from a message, it's delivered too
+type messageAssembler struct {
+	maxSize int
+
+	buf     []byte
+	scanner jsonScanner
+	resyncs []*resync
+
+	// the pending message is malformed, the resyncs are still scanned to
+	// pick the one replacing it
+	malformed bool
+
+	// waiting for a frame starting a new message
+	skipping bool
+}
+
+func newMessageAssembler(maxSize int) *messageAssembler {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxMessageSize
+	}
+
+	return &messageAssembler{
+		maxSize: maxSize,
+	}
+}
+
+// drop the message being assembled and wait for the next one
+func (a *messageAssembler) reset() {
+	a.buf = nil
+	a.scanner = jsonScanner{}
+	a.resyncs = nil
+	a.malformed = false
+	a.skipping = true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// whether a frame starts a message
+func startsMessage(frame []byte) bool {
+	for _, c := range frame {
+		if !isSpace(c) {
+			return c == '{'
+		}
+	}
+
+	return false
+}
+
+// scan a buffered byte with the resyncs, the ones it's a syntax error for are dropped
+func (a *messageAssembler) stepResyncs(c byte) {
+	live := a.resyncs[:0]
+
+	for _, r := range a.resyncs {
+		if r.scanner.idle() {
+			if isSpace(c) {
+				live = append(live, r)
+				continue
+			}
+
+			r.start = len(a.buf) - 1
+		}
+
+		completed, ok := r.scanner.step(c)
+
+		if !ok {
+			continue
+		}
+
+		if completed {
+			r.completed = append(r.completed, [2]int{r.start, len(a.buf)})
+		}
+
+		live = append(live, r)
+	}
+
+	a.resyncs = live
+}
+
+// once the pending message is malformed, pick the resync replacing it when
+// only one is left, or when they all are between messages, in which case the
+// one dropping the least is kept. Returns false while it's undecided
+func (a *messageAssembler) resolve() (*resync, bool) {
+	if len(a.resyncs) == 1 {
+		return a.resyncs[0], true
+	}
+
+	var earliest *resync
+
+	for _, r := range a.resyncs {
+		if !r.scanner.idle() {
+			return nil, false
+		}
+
+		if earliest == nil || r.first() < earliest.first() {
+			earliest = r
+		}
+	}
+
+	return earliest, true
+}
+
+// replace the malformed pending message with a resync, returns the messages
+// the resync completed
+func (a *messageAssembler) takeOver(r *resync) [][]byte {
+	messages := [][]byte{}
+
+	for _, span := range r.completed {
+		messages = append(messages, a.buf[span[0]:span[1]])
+	}
+
+	a.scanner = r.scanner
+	a.resyncs = nil
+	a.malformed = false
+
+	if r.scanner.idle() {
+		a.buf = nil
+	} else {
+		a.buf = append([]byte{}, a.buf[r.start:]...)
+	}
+
+	return messages
+}
+
+// feed a frame, returns the messages it completed and an error if part of it
+// was dropped
+func (a *messageAssembler) feed(frame []byte) ([][]byte, error) {
+	if a.skipping {
+		if !startsMessage(frame) {
+			return nil, nil
+		}
+
+		a.skipping = false
+	}
+
+	if !a.scanner.idle() && !a.malformed && startsMessage(frame) && len(a.resyncs) < maxResyncs {
+		a.resyncs = append(a.resyncs, &resync{})
+	}
+
+	messages := [][]byte{}
+
+	var dropped error
+
+	for i, c := range frame {
+		if a.scanner.idle() && isSpace(c) {
+			continue
+		}
+
+		if len(a.buf) >= a.maxSize {
+			a.reset()
+			return messages, errors.Join(dropped, fmt.Errorf("%w: over %d bytes", ErrMessageTooLarge, a.maxSize))
+		}
+
+		a.buf = append(a.buf, c)
+
+		if len(a.resyncs) > 0 {
+			a.stepResyncs(c)
+		}
+
+		if !a.malformed {
+			completed, ok := a.scanner.step(c)
+
+			if completed {
+				messages = append(messages, a.buf)
+				a.buf = nil
+				a.resyncs = nil
+				continue
+			}
+
+			a.malformed = !ok
+		}
+
+		if !a.malformed {
+			continue
+		}
+
+		if len(a.resyncs) == 0 {
+			a.reset()
+			return messages, errors.Join(dropped, fmt.Errorf("%w: unexpected %q at offset %d", ErrMalformedFrame, c, i))
+		}
+
+		if r, ok := a.resolve(); ok {
+			dropped = fmt.Errorf("%w: dropped a message cut short, %d bytes", ErrMalformedFrame, r.first())
+			messages = append(messages, a.takeOver(r)...)
+		}
+	}
+
+	return messages, dropped
+}
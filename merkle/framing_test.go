@@ -0,0 +1,224 @@
+package merkle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// json messages with the characters that matter to the assembler in their strings
+func testMessages(r *rand.Rand, count int) [][]byte {
+	chars := []rune("{}[]\"\\ab \n\té")
+
+	randString := func() string {
+		s := make([]rune, r.Intn(24))
+
+		for i := range s {
+			s[i] = chars[r.Intn(len(chars))]
+		}
+
+		return string(s)
+	}
+
+	messages := [][]byte{}
+
+	for i := 0; i < count; i++ {
+		message, _ := json.Marshal(map[string]interface{}{
+			"id":     i,
+			"text":   randString(),
+			"nested": []interface{}{map[string]interface{}{"key": randString()}, randString(), []int{}},
+		})
+
+		messages = append(messages, message)
+	}
+
+	return messages
+}
+
+// split data in frames, the sizes are read from cuts
+func splitFrames(data []byte, cuts []byte) [][]byte {
+	frames := [][]byte{}
+
+	for i := 0; len(data) > 0; i++ {
+		size := len(data)
+
+		if len(cuts) > 0 {
+			size = int(cuts[i%len(cuts)])%64 + 1
+		}
+
+		if size > len(data) {
+			size = len(data)
+		}
+
+		frames = append(frames, data[:size])
+		data = data[size:]
+	}
+
+	return frames
+}
+
+func FuzzMessageAssembler(f *testing.F) {
+	f.Add(int64(1), uint8(3), []byte{1}, []byte(nil))
+	f.Add(int64(2), uint8(5), []byte{7, 200, 3}, []byte("x}"))
+	f.Add(int64(3), uint8(1), []byte{}, []byte("{"))
+	f.Add(int64(4), uint8(8), []byte{63, 0, 12}, []byte("\"{}[]"))
+
+	f.Fuzz(func(t *testing.T, seed int64, count uint8, cuts []byte, garbage []byte) {
+		r := rand.New(rand.NewSource(seed))
+
+		before := testMessages(r, int(count%8)+1)
+		after := testMessages(r, int(count%5)+1)
+
+		frames := splitFrames(bytes.Join(before, []byte("\n")), cuts)
+
+		// a corrupt frame between the messages, then messages starting frames
+		corrupt := garbage != nil
+
+		if corrupt {
+			frames = append(frames, append([]byte("]"), garbage...))
+		}
+
+		for _, message := range after {
+			frames = append(frames, splitFrames(message, cuts)...)
+		}
+
+		assembler := newMessageAssembler(0)
+
+		got := [][]byte{}
+		errs := 0
+
+		for _, frame := range frames {
+			messages, err := assembler.feed(frame)
+
+			got = append(got, messages...)
+
+			if err != nil {
+				if !errors.Is(err, ErrMalformedFrame) {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				errs++
+			}
+		}
+
+		want := append(append([][]byte{}, before...), after...)
+
+		if len(got) != len(want) {
+			t.Fatalf("got %d messages, want %d", len(got), len(want))
+		}
+
+		for i := range want {
+			if !bytes.Equal(got[i], want[i]) {
+				t.Fatalf("message %d: got %s, want %s", i, got[i], want[i])
+			}
+		}
+
+		if corrupt && errs != 1 {
+			t.Fatalf("got %d errors for a corrupt frame, want 1", errs)
+		}
+
+		if !corrupt && errs != 0 {
+			t.Fatalf("got %d errors for valid frames", errs)
+		}
+	})
+}
+
+func FuzzMessageAssemblerCutShort(f *testing.F) {
+	f.Add(int64(1), uint8(3), []byte{1}, uint16(10))
+	f.Add(int64(2), uint8(5), []byte{7, 200, 3}, uint16(1))
+	f.Add(int64(3), uint8(1), []byte{}, uint16(1000))
+	f.Add(int64(4), uint8(8), []byte{63, 0, 12}, uint16(25))
+
+	f.Fuzz(func(t *testing.T, seed int64, count uint8, cuts []byte, cut uint16) {
+		r := rand.New(rand.NewSource(seed))
+
+		before := testMessages(r, int(count%8)+1)
+		after := testMessages(r, int(count%5)+2)
+
+		// a message cut mid-way, then messages starting frames
+		short := testMessages(r, 1)[0]
+		short = short[:int(cut)%(len(short)-1)+1]
+
+		frames := splitFrames(bytes.Join(before, []byte("\n")), cuts)
+		frames = append(frames, splitFrames(short, cuts)...)
+
+		for _, message := range after {
+			frames = append(frames, splitFrames(message, cuts)...)
+		}
+
+		assembler := newMessageAssembler(0)
+
+		got := [][]byte{}
+		errs := 0
+
+		for _, frame := range frames {
+			messages, err := assembler.feed(frame)
+
+			got = append(got, messages...)
+
+			if err != nil {
+				if !errors.Is(err, ErrMalformedFrame) {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				errs++
+			}
+		}
+
+		if len(got) < len(before)+len(after) {
+			t.Fatalf("got %d messages, want at least %d", len(got), len(before)+len(after))
+		}
+
+		extra := got[len(before) : len(got)-len(after)]
+
+		for i, message := range append(append([][]byte{}, before...), after...) {
+			if i >= len(before) {
+				i += len(extra)
+			}
+
+			if !bytes.Equal(got[i], message) {
+				t.Fatalf("message %d: got %s, want %s", i, got[i], message)
+			}
+		}
+
+		// objects of the message cut short that started a frame
+		for _, message := range extra {
+			if !bytes.Contains(short, message) {
+				t.Fatalf("got %s, not part of the message cut short", message)
+			}
+		}
+
+		if errs != 1 {
+			t.Fatalf("got %d errors for a message cut short, want 1", errs)
+		}
+	})
+}
+
+func TestMessageAssemblerTooLarge(t *testing.T) {
+	messages := testMessages(rand.New(rand.NewSource(1)), 3)
+
+	maxSize := len(messages[0]) + len(messages[2])
+
+	assembler := newMessageAssembler(maxSize)
+
+	large, _ := json.Marshal(map[string]string{"text": strings.Repeat("{", maxSize)})
+
+	got := [][]byte{}
+
+	for i, frame := range [][]byte{messages[0], large, messages[2]} {
+		out, err := assembler.feed(frame)
+
+		got = append(got, out...)
+
+		if (i == 1) != errors.Is(err, ErrMessageTooLarge) {
+			t.Fatalf("frame %d: unexpected error %v", i, err)
+		}
+	}
+
+	if len(got) != 2 || !bytes.Equal(got[0], messages[0]) || !bytes.Equal(got[1], messages[2]) {
+		t.Fatalf("got %q", got)
+	}
+}
@@ -7,8 +7,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"time"
 )
 
+// the timeout of requests to the merkle apis, on top of the context
+var DefaultHTTPTimeout = 30 * time.Second
+
+func doRequest(req *http.Request) (*http.Response, error) {
+	client := &http.Client{Timeout: DefaultHTTPTimeout}
+
+	return client.Do(req)
+}
+
 func MakePost(ctx context.Context, url string, apiKey string, body interface{}, resp interface{}) error {
 	bodyBytes, err := json.Marshal(body)
 
@@ -31,9 +41,7 @@ func MakePost(ctx context.Context, url string, apiKey string, body interface{},
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Token "+apiKey)
 
-	client := &http.Client{}
-
-	res, err := client.Do(req)
+	res, err := doRequest(req)
 
 	if err != nil {
 		return fmt.Errorf("error sending request: %v", err)
@@ -48,8 +56,8 @@ func MakePost(ctx context.Context, url string, apiKey string, body interface{},
 		return fmt.Errorf("error reading response: %v", err)
 	}
 
-	if res.StatusCode > 400 {
-		return fmt.Errorf("error sending request: url=%s code=%s, body=%s", url, res.Status, bodyRead)
+	if res.StatusCode >= 400 {
+		return &APIError{URL: url, StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
 	}
 
 	if resp == nil {
@@ -87,9 +95,7 @@ func MakeDel(ctx context.Context, url string, apiKey string, body interface{}, r
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Token "+apiKey)
 
-	client := &http.Client{}
-
-	res, err := client.Do(req)
+	res, err := doRequest(req)
 
 	if err != nil {
 		return fmt.Errorf("error sending request: %v", err)
@@ -104,8 +110,8 @@ func MakeDel(ctx context.Context, url string, apiKey string, body interface{}, r
 		return fmt.Errorf("error reading response: %v", err)
 	}
 
-	if res.StatusCode > 400 {
-		return fmt.Errorf("error sending request: url=%s, code=%s, body=%s", url, res.Status, bodyRead)
+	if res.StatusCode >= 400 {
+		return &APIError{URL: url, StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
 	}
 
 	if resp == nil {
@@ -131,23 +137,30 @@ func MakeGet(ctx context.Context, url string, apiKey string, resp interface{}) e
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Token "+apiKey)
 
-	client := &http.Client{}
-
-	res, err := client.Do(req)
+	res, err := doRequest(req)
 
 	if err != nil {
 		return fmt.Errorf("error sending request: %v", err)
 	}
 
-	if res.StatusCode > 400 {
-		return fmt.Errorf("error sending request: code=%s", res.Status)
+	// read the whole body
+	defer res.Body.Close()
+
+	bodyRead, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return fmt.Errorf("error reading response: %v", err)
+	}
+
+	if res.StatusCode >= 400 {
+		return &APIError{URL: url, StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
 	}
 
 	if resp == nil {
 		return nil
 	}
 
-	err = json.NewDecoder(res.Body).Decode(&resp)
+	err = json.Unmarshal(bodyRead, &resp)
 
 	if err != nil {
 		return fmt.Errorf("error decoding response: %v", err)
@@ -0,0 +1,157 @@
+package merkle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// a legacy transaction signed without a chain id (pre EIP-155), its chain can't
+// be told from the transaction
+var ErrNoChainId = errors.New("transaction has no chain id")
+
+// the outcome of injecting one transaction of a batch
+type InjectResult struct {
+	Transaction *types.Transaction
+	Hash        common.Hash
+
+	// why the transaction was rejected, a *RPCError for node errors
+	Err error
+}
+
+// inject many transactions of a chain in one json-rpc batch request. The results
+// are in the order of the transactions, the error is only set if the whole
+// request failed
+func (t *TransactionStream) InjectBatch(ctx context.Context, chainId MerkleChainId, txs []*types.Transaction) ([]InjectResult, error) {
+	results := make([]InjectResult, len(txs))
+
+	if len(txs) == 0 {
+		return results, nil
+	}
+
+	batch := []*rpcRequest{}
+
+	for i, tx := range txs {
+		results[i].Transaction = tx
+
+		bts, err := tx.MarshalBinary()
+
+		if err != nil {
+			return nil, fmt.Errorf("error marshalling tx %d: %s", i, err)
+		}
+
+		batch = append(batch, &rpcRequest{
+			Jsonrpc: "2.0",
+			Method:  "eth_sendRawTransaction",
+			Params:  []string{fmt.Sprintf("0x%x", bts)},
+			Id:      i,
+		})
+	}
+
+	var raw json.RawMessage
+
+	if err := postRpc(ctx, t.rpcUrl(chainId), t.sdk.ApiKey, batch, &raw); err != nil {
+		return nil, fmt.Errorf("error injecting txs: %w", err)
+	}
+
+	var responses []rpcResponse
+
+	if err := json.Unmarshal(raw, &responses); err != nil {
+		// the whole batch was rejected with a single error
+		var single rpcResponse
+
+		if json.Unmarshal(raw, &single) == nil && single.Error != nil {
+			return nil, fmt.Errorf("error injecting txs: %w", single.Error)
+		}
+
+		return nil, fmt.Errorf("error decoding response: %s", err)
+	}
+
+	answered := make([]bool, len(txs))
+
+	// responses of a batch can come in any order
+	for _, res := range responses {
+		var id int
+
+		if err := json.Unmarshal(res.Id, &id); err != nil || id < 0 || id >= len(txs) {
+			continue
+		}
+
+		answered[id] = true
+		results[id].Hash, results[id].Err = res.txHash()
+	}
+
+	for i := range results {
+		if !answered[i] {
+			results[i].Err = fmt.Errorf("no response for transaction %s", txs[i].Hash().String())
+		}
+	}
+
+	return results, nil
+}
+
+// inject transactions of any chain, grouped by chain id, one batch request per
+// chain at the same time. The results are in the order of the transactions, the
+// error joins the errors of the batch requests that failed as a whole. Nothing is
+// sent if a transaction has no chain id, inject those with InjectBatch
+func (t *TransactionStream) InjectMany(ctx context.Context, txs []*types.Transaction) ([]InjectResult, error) {
+	groups := map[int64][]int{}
+
+	for i, tx := range txs {
+		if tx.ChainId().Sign() == 0 {
+			return nil, fmt.Errorf("%w: tx %d (%s)", ErrNoChainId, i, tx.Hash().String())
+		}
+
+		chainId := tx.ChainId().Int64()
+		groups[chainId] = append(groups[chainId], i)
+	}
+
+	results := make([]InjectResult, len(txs))
+	errs := []error{}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for chainId, indexes := range groups {
+		wg.Add(1)
+
+		go func(chainId int64, indexes []int) {
+			defer wg.Done()
+
+			group := make([]*types.Transaction, len(indexes))
+
+			for i, index := range indexes {
+				group[i] = txs[index]
+			}
+
+			groupResults, err := t.InjectBatch(ctx, MerkleChainId(chainId), group)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				err = fmt.Errorf("chain %d: %w", chainId, err)
+				errs = append(errs, err)
+
+				for _, index := range indexes {
+					results[index] = InjectResult{Transaction: txs[index], Err: err}
+				}
+
+				return
+			}
+
+			for i, index := range indexes {
+				results[index] = groupResults[i]
+			}
+		}(chainId, indexes)
+	}
+
+	wg.Wait()
+
+	return results, errors.Join(errs...)
+}
@@ -0,0 +1,102 @@
+package merkle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+)
+
+func TestInjectManyGroupsByChain(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+
+	polygon, err := types.SignNewTx(key, types.LatestSignerForChainID(big.NewInt(137)), &types.DynamicFeeTx{
+		ChainID:   big.NewInt(137),
+		GasTipCap: big.NewInt(1e9),
+		GasFeeCap: big.NewInt(30e9),
+		Gas:       21000,
+		To:        &to,
+	})
+
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	txs := []*types.Transaction{testutil.SignedTransaction(t, key, 0, &to), polygon, testutil.SignedTransaction(t, key, 1, &to)}
+
+	var mu sync.Mutex
+	batches := map[string]int{}
+
+	// answers every transaction with the chain of the request
+	testutil.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		batch := []rpcRequest{}
+		json.NewDecoder(r.Body).Decode(&batch)
+
+		mu.Lock()
+		batches[r.URL.Path] = len(batch)
+		mu.Unlock()
+
+		chain := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		responses := []string{}
+
+		for _, req := range batch {
+			responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"result":"%s"}`, req.Id, common.HexToHash("0x"+chain)))
+		}
+
+		fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+	}))
+
+	sdk := New()
+	sdk.SetApiKey("test")
+
+	results, err := sdk.Transactions().InjectMany(context.Background(), txs)
+
+	if err != nil {
+		t.Fatalf("failed to inject: %s", err)
+	}
+
+	if batches["/rpc/test/1"] != 2 || batches["/rpc/test/137"] != 1 || len(batches) != 2 {
+		t.Fatalf("got batches %v", batches)
+	}
+
+	for i, want := range []string{"0x1", "0x137", "0x1"} {
+		if results[i].Transaction != txs[i] || results[i].Err != nil || results[i].Hash != common.HexToHash(want) {
+			t.Fatalf("result %d: got %+v", i, results[i])
+		}
+	}
+}
+
+func TestInjectManyRefusesTransactionsWithoutChainId(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	to := common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+
+	legacy, err := types.SignTx(types.NewTx(&types.LegacyTx{GasPrice: big.NewInt(30e9), Gas: 21000, To: &to}), types.HomesteadSigner{}, key)
+
+	if err != nil {
+		t.Fatalf("failed to sign: %s", err)
+	}
+
+	testutil.Serve(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("nothing should be sent, got a request to %s", r.URL.Path)
+	}))
+
+	sdk := New()
+	sdk.SetApiKey("test")
+
+	_, err = sdk.Transactions().InjectMany(context.Background(), []*types.Transaction{testutil.SignedTransaction(t, key, 0, &to), legacy})
+
+	if !errors.Is(err, ErrNoChainId) {
+		t.Fatalf("got error %v, want ErrNoChainId", err)
+	}
+}
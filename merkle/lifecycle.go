@@ -0,0 +1,453 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// where InjectAndConfirm looks for receipts, e.g. an ethclient.Client
+// connected to a node, or a go-ethereum simulated backend
+type ReceiptSource interface {
+	// returns ethereum.NotFound while the transaction isn't included
+	TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+}
+
+// optionally implemented by a ReceiptSource, to know when the transaction
+// reached the node's mempool (ethclient.Client implements it)
+type PendingSource interface {
+	TransactionByHash(ctx context.Context, hash common.Hash) (tx *types.Transaction, isPending bool, err error)
+}
+
+// optionally implemented by a ReceiptSource, to know when the nonce of the
+// transaction was used by another transaction (ethclient.Client implements it)
+type NonceSource interface {
+	NonceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (uint64, error)
+}
+
+type LifecycleEventType string
+
+const (
+	// the transaction was injected (or rebroadcast)
+	TxInjected LifecycleEventType = "injected"
+
+	// the transaction reached the mempool
+	TxSeen LifecycleEventType = "seen"
+
+	// the transaction was replaced by one with bumped fees
+	TxReplaced LifecycleEventType = "replaced"
+
+	// the transaction (or one of its replacements) was included in a block
+	TxIncluded LifecycleEventType = "included"
+
+	// the transaction won't be included, see the reason
+	TxDropped LifecycleEventType = "dropped"
+)
+
+type LifecycleEvent struct {
+	Type LifecycleEventType
+	Time time.Time
+
+	// the transaction the event is about, the replacement for TxReplaced
+	Transaction *types.Transaction
+
+	// the replaced transaction, for TxReplaced
+	Replaces *types.Transaction
+
+	// the receipt, for TxIncluded
+	Receipt *types.Receipt
+
+	// why the transaction was dropped, for TxDropped
+	Reason string
+}
+
+type ConfirmOptions struct {
+	// where to look for receipts, required
+	Receipts ReceiptSource
+
+	// how often to look for receipts, defaults to 2 seconds
+	PollInterval time.Duration
+
+	// how often to inject the transaction again, defaults to 12 seconds
+	RebroadcastInterval time.Duration
+
+	// re-sign replacements with bumped fees when the transaction is stuck,
+	// optional, the signer must hold the key of the sender
+	Signer Signer
+
+	// bump the fees when the transaction isn't included after this long,
+	// defaults to 1 minute
+	StuckAfter time.Duration
+
+	// percentage the fees are bumped by, at least 10 for nodes to accept
+	// the replacement, defaults to 15
+	BumpPercent int64
+
+	// never bump the fee cap (or gas price) above this, optional
+	MaxFeeCap *big.Int
+
+	// drop the transaction if it isn't included after this long, 0 to wait
+	// until the context is done
+	MaxWait time.Duration
+}
+
+func (o *ConfirmOptions) withDefaults() ConfirmOptions {
+	opts := ConfirmOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = 2 * time.Second
+	}
+
+	if opts.RebroadcastInterval <= 0 {
+		opts.RebroadcastInterval = 12 * time.Second
+	}
+
+	if opts.StuckAfter <= 0 {
+		opts.StuckAfter = 1 * time.Minute
+	}
+
+	if opts.BumpPercent < 10 {
+		opts.BumpPercent = 15
+	}
+
+	return opts
+}
+
+// inject a transaction and follow it until it's included or dropped, rebroadcasting
+// it and, with a Signer, replacing it with bumped fees when it's stuck. The
+// subscription is closed after the TxIncluded or TxDropped event
+func (t *TransactionStream) InjectAndConfirm(ctx context.Context, chainId MerkleChainId, tx *types.Transaction, options *ConfirmOptions) (*Subscription[*LifecycleEvent], error) {
+	opts := options.withDefaults()
+
+	if opts.Receipts == nil {
+		return nil, fmt.Errorf("a receipt source is required")
+	}
+
+	from, err := types.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction sender: %s", err)
+	}
+
+	if opts.Signer != nil && opts.Signer.Address() != from {
+		return nil, fmt.Errorf("signer %s can't replace transactions of %s", opts.Signer.Address().String(), from.String())
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := NewSubscription(make(chan *LifecycleEvent), make(chan error))
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
+
+	l := &lifecycle{
+		stream:  t,
+		chainId: chainId,
+		from:    from,
+		opts:    opts,
+		sub:     sub,
+		current: tx,
+		sent:    []*types.Transaction{tx},
+	}
+
+	go func() {
+		defer sub.Close()
+
+		l.run(ctx)
+	}()
+
+	return sub, nil
+}
+
+type lifecycle struct {
+	stream  *TransactionStream
+	chainId MerkleChainId
+	from    common.Address
+	opts    ConfirmOptions
+	sub     *Subscription[*LifecycleEvent]
+
+	// the transaction being broadcast, and every version sent so far
+	current *types.Transaction
+	sent    []*types.Transaction
+	seen    bool
+}
+
+func (l *lifecycle) emit(event *LifecycleEvent) {
+	event.Time = time.Now()
+	l.sub.Push(event)
+}
+
+// inject a transaction, a transaction the node already has is fine
+func (l *lifecycle) inject(ctx context.Context, tx *types.Transaction) error {
+	_, err := l.stream.Inject(ctx, l.chainId, tx)
+
+	if err != nil && !errors.Is(err, ErrAlreadyKnown) {
+		return err
+	}
+
+	return nil
+}
+
+func (l *lifecycle) run(ctx context.Context) {
+	if err := l.inject(ctx, l.current); err != nil {
+		if errors.Is(err, ErrNonceTooLow) {
+			l.nonceTooLow(ctx)
+			return
+		}
+
+		// other errors are final
+		l.emit(&LifecycleEvent{Type: TxDropped, Transaction: l.current, Reason: fmt.Sprintf("injection failed: %s", err)})
+		return
+	}
+
+	l.emit(&LifecycleEvent{Type: TxInjected, Transaction: l.current})
+
+	start := time.Now()
+	lastBroadcast := start
+	lastBump := start
+
+	ticker := time.NewTicker(l.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		if l.checkIncluded(ctx) {
+			return
+		}
+
+		l.checkSeen(ctx)
+
+		if l.checkNonceUsed(ctx) {
+			return
+		}
+
+		switch {
+		case l.opts.Signer != nil && time.Since(lastBump) >= l.opts.StuckAfter:
+			lastBump = time.Now()
+
+			if l.replace(ctx) {
+				lastBroadcast = time.Now()
+			}
+		case time.Since(lastBroadcast) >= l.opts.RebroadcastInterval:
+			lastBroadcast = time.Now()
+
+			err := l.inject(ctx, l.current)
+
+			switch {
+			case errors.Is(err, ErrNonceTooLow):
+				l.nonceTooLow(ctx)
+				return
+			case err != nil:
+				l.sub.PushError(fmt.Errorf("error rebroadcasting transaction: %w", err))
+			default:
+				l.emit(&LifecycleEvent{Type: TxInjected, Transaction: l.current})
+			}
+		}
+
+		if l.opts.MaxWait > 0 && time.Since(start) >= l.opts.MaxWait {
+			l.emit(&LifecycleEvent{Type: TxDropped, Transaction: l.current, Reason: fmt.Sprintf("not included after %s", l.opts.MaxWait)})
+			return
+		}
+	}
+}
+
+// look for the receipt of every version of the transaction
+func (l *lifecycle) checkIncluded(ctx context.Context) bool {
+	for i := len(l.sent) - 1; i >= 0; i-- {
+		receipt, err := l.opts.Receipts.TransactionReceipt(ctx, l.sent[i].Hash())
+
+		if errors.Is(err, ethereum.NotFound) || (err == nil && receipt == nil) {
+			continue
+		}
+
+		if err != nil {
+			if ctx.Err() == nil {
+				l.sub.PushError(fmt.Errorf("error fetching receipt: %w", err))
+			}
+			return false
+		}
+
+		l.emit(&LifecycleEvent{Type: TxIncluded, Transaction: l.sent[i], Receipt: receipt})
+		return true
+	}
+
+	return false
+}
+
+func (l *lifecycle) checkSeen(ctx context.Context) {
+	if l.seen {
+		return
+	}
+
+	if pending, ok := l.opts.Receipts.(PendingSource); ok {
+		if _, _, err := pending.TransactionByHash(ctx, l.current.Hash()); err != nil {
+			return
+		}
+	} else if _, err := l.stream.Trace(ctx, l.current.Hash().String()); err != nil {
+		return
+	}
+
+	l.seen = true
+	l.emit(&LifecycleEvent{Type: TxSeen, Transaction: l.current})
+}
+
+// the node refused the transaction because its nonce was used, by one of
+// ours if it's included, otherwise by another transaction
+func (l *lifecycle) nonceTooLow(ctx context.Context) {
+	if l.checkIncluded(ctx) {
+		return
+	}
+
+	l.emit(&LifecycleEvent{Type: TxDropped, Transaction: l.current, Reason: "nonce used by another transaction"})
+}
+
+// the nonce was used by a transaction that isn't one of ours
+func (l *lifecycle) checkNonceUsed(ctx context.Context) bool {
+	nonces, ok := l.opts.Receipts.(NonceSource)
+
+	if !ok {
+		return false
+	}
+
+	nonce, err := nonces.NonceAt(ctx, l.from, nil)
+
+	if err != nil || nonce <= l.current.Nonce() {
+		return false
+	}
+
+	// one of ours may have been included since the receipts were checked
+	if l.checkIncluded(ctx) {
+		return true
+	}
+
+	l.emit(&LifecycleEvent{Type: TxDropped, Transaction: l.current, Reason: "nonce used by another transaction"})
+	return true
+}
+
+// replace the current transaction with one with bumped fees
+func (l *lifecycle) replace(ctx context.Context) bool {
+	unsigned, ok := bumpFees(l.current, l.opts.BumpPercent, l.opts.MaxFeeCap)
+
+	if !ok {
+		// already at the maximum fee cap
+		return false
+	}
+
+	replacement, err := l.opts.Signer.SignTx(l.current.ChainId(), unsigned)
+
+	if err != nil {
+		l.sub.PushError(fmt.Errorf("error signing replacement: %w", err))
+		return false
+	}
+
+	if err := l.inject(ctx, replacement); err != nil {
+		l.sub.PushError(fmt.Errorf("error injecting replacement: %w", err))
+		return false
+	}
+
+	l.emit(&LifecycleEvent{Type: TxReplaced, Transaction: replacement, Replaces: l.current})
+
+	l.current = replacement
+	l.sent = append(l.sent, replacement)
+	l.seen = false
+
+	return true
+}
+
+func bump(value *big.Int, percent int64) *big.Int {
+	bumped := new(big.Int).Mul(value, big.NewInt(100+percent))
+	bumped.Div(bumped, big.NewInt(100))
+
+	// always move, even for tiny values
+	if bumped.Cmp(value) <= 0 {
+		bumped.Add(value, big.NewInt(1))
+	}
+
+	return bumped
+}
+
+func capAt(value *big.Int, max *big.Int) *big.Int {
+	if max != nil && value.Cmp(max) > 0 {
+		return new(big.Int).Set(max)
+	}
+
+	return value
+}
+
+// an unsigned copy of a transaction with its fees bumped by percent, false if the
+// fee cap (or gas price) can't be bumped without going over maxFeeCap
+func bumpFees(tx *types.Transaction, percent int64, maxFeeCap *big.Int) (*types.Transaction, bool) {
+	switch tx.Type() {
+	case types.LegacyTxType:
+		gasPrice := capAt(bump(tx.GasPrice(), percent), maxFeeCap)
+
+		if gasPrice.Cmp(tx.GasPrice()) <= 0 {
+			return nil, false
+		}
+
+		return types.NewTx(&types.LegacyTx{
+			Nonce:    tx.Nonce(),
+			GasPrice: gasPrice,
+			Gas:      tx.Gas(),
+			To:       tx.To(),
+			Value:    tx.Value(),
+			Data:     tx.Data(),
+		}), true
+	case types.AccessListTxType:
+		gasPrice := capAt(bump(tx.GasPrice(), percent), maxFeeCap)
+
+		if gasPrice.Cmp(tx.GasPrice()) <= 0 {
+			return nil, false
+		}
+
+		return types.NewTx(&types.AccessListTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasPrice:   gasPrice,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}), true
+	default:
+		feeCap := capAt(bump(tx.GasFeeCap(), percent), maxFeeCap)
+		tipCap := bump(tx.GasTipCap(), percent)
+
+		if feeCap.Cmp(tx.GasFeeCap()) <= 0 {
+			return nil, false
+		}
+
+		if tipCap.Cmp(feeCap) > 0 {
+			tipCap = feeCap
+		}
+
+		return types.NewTx(&types.DynamicFeeTx{
+			ChainID:    tx.ChainId(),
+			Nonce:      tx.Nonce(),
+			GasTipCap:  tipCap,
+			GasFeeCap:  feeCap,
+			Gas:        tx.Gas(),
+			To:         tx.To(),
+			Value:      tx.Value(),
+			Data:       tx.Data(),
+			AccessList: tx.AccessList(),
+		}), true
+	}
+}
@@ -0,0 +1,111 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+)
+
+// receipts of the transactions in included
+type testReceipts struct {
+	included map[common.Hash]bool
+}
+
+func (r *testReceipts) TransactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	if r.included[hash] {
+		return &types.Receipt{TxHash: hash, Status: types.ReceiptStatusSuccessful}, nil
+	}
+
+	return nil, ethereum.NotFound
+}
+
+// an injection answered with a node error
+func injectionError(message string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":%q}}`, message)
+	}
+}
+
+// the events of a lifecycle until its subscription closes
+func lifecycleEvents(t *testing.T, sub *Subscription[*LifecycleEvent]) []*LifecycleEvent {
+	t.Helper()
+
+	events := []*LifecycleEvent{}
+
+	for {
+		select {
+		case event := <-sub.Items():
+			events = append(events, event)
+		case err := <-sub.Err():
+			t.Fatalf("unexpected error: %s", err)
+		case <-sub.Done():
+			return events
+		case <-time.After(5 * time.Second):
+			t.Fatalf("the subscription wasn't closed")
+		}
+	}
+}
+
+func TestInjectAndConfirmEndsWithEvent(t *testing.T) {
+	key, err := crypto.GenerateKey()
+
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	to := common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	tx := testutil.SignedTransaction(t, key, 0, &to)
+
+	tests := []struct {
+		name     string
+		message  string
+		included bool
+		want     LifecycleEventType
+		reason   string
+	}{
+		{"rejected", "insufficient funds for gas * price + value", false, TxDropped, "injection failed"},
+		{"nonce used by another transaction", "nonce too low", false, TxDropped, "nonce used"},
+		{"nonce used by the transaction", "nonce too low", true, TxIncluded, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			testutil.Serve(t, injectionError(test.message))
+
+			sdk := New()
+			sdk.SetApiKey("test")
+
+			receipts := &testReceipts{included: map[common.Hash]bool{tx.Hash(): test.included}}
+
+			sub, err := sdk.Transactions().InjectAndConfirm(context.Background(), EthereumMainnet, tx, &ConfirmOptions{Receipts: receipts})
+
+			if err != nil {
+				t.Fatalf("failed to inject: %s", err)
+			}
+
+			// nothing was injected, the only event is the last one
+			events := lifecycleEvents(t, sub)
+
+			if len(events) != 1 {
+				t.Fatalf("got %d events, want 1", len(events))
+			}
+
+			if events[0].Type != test.want {
+				t.Fatalf("got event %s, want %s", events[0].Type, test.want)
+			}
+
+			if !strings.Contains(events[0].Reason, test.reason) {
+				t.Fatalf("got reason %q, want %q", events[0].Reason, test.reason)
+			}
+		})
+	}
+}
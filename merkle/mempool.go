@@ -2,34 +2,82 @@ package merkle
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math/big"
+	"net"
 	"net/http"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"golang.org/x/net/websocket"
 )
 
 type PrivatePool struct {
 	sdk *MerkleSDK
+
+	bids *bidRecorder
 }
 
 func NewPrivatePool(sdk *MerkleSDK) *PrivatePool {
 	return &PrivatePool{
-		sdk: sdk,
+		sdk:  sdk,
+		bids: newBidRecorder(),
 	}
 }
 
+// the transaction of an auction, as much as its privacy settings reveal. Hidden
+// fields are nil, use Reveals to tell a hidden field from an empty one, e.g. a
+// nil To is a contract creation only if the destination is revealed
 type AuctionTransaction struct {
 	Hash  common.Hash
-	From  common.Address
-	To    common.Address
+	From  *common.Address
+	To    *common.Address
 	Value *big.Int
-	Data  []byte
-	Gas   uint64
+	Gas   *uint64
+
+	// the full calldata
+	Data []byte
+
+	// the first 4 bytes of the calldata, set when the calldata or the function selector is revealed
+	FunctionSelector []byte
+
+	// the logs the transaction emits when simulated
+	Logs []*AuctionLog
+
+	// the fields the transaction reveals
+	Hints []Hint
+
+	// the fees of the transaction, nil when the pool doesn't share them.
+	// GasPrice for legacy transactions, the caps for dynamic fee ones
+	GasPrice  *big.Int
+	GasFeeCap *big.Int
+	GasTipCap *big.Int
+}
+
+// whether the transaction reveals a field
+func (t *AuctionTransaction) Reveals(hint Hint) bool {
+	if hint == HintHash {
+		return true
+	}
+
+	if hint == HintFunctionSelector && supported(HintCalldata, t.Hints) {
+		return true
+	}
+
+	return supported(hint, t.Hints)
+}
+
+// a log of a simulated auction transaction
+type AuctionLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
 }
 
 type Auction struct {
@@ -41,6 +89,15 @@ type Auction struct {
 	Connection   *websocket.Conn
 
 	Transaction *AuctionTransaction
+
+	// set for the auctions streamed by a pool, see AuctionsOptions
+	bidMargin time.Duration
+	pool      *PrivatePool
+}
+
+// whether the transaction of the auction reveals a field
+func (a *Auction) Reveals(hint Hint) bool {
+	return a.Transaction != nil && a.Transaction.Reveals(hint)
 }
 
 type RawRpcResponse struct {
@@ -48,72 +105,261 @@ type RawRpcResponse struct {
 	Result  string `json:"result"`
 }
 
+// hidden fields are missing from the feed
+type RawAuctionTransaction struct {
+	Data             *string       `json:"data,omitempty"`
+	From             *string       `json:"from,omitempty"`
+	Gas              *int64        `json:"gas,omitempty"`
+	Hash             string        `json:"hash"`
+	To               *string       `json:"to,omitempty"`
+	Value            *string       `json:"value,omitempty"`
+	FunctionSelector *string       `json:"function_selector,omitempty"`
+	Logs             []*AuctionLog `json:"logs,omitempty"`
+
+	// the revealed fields, inferred from the fields present if missing
+	Hints []string `json:"hints,omitempty"`
+
+	// decimal strings, not a privacy hint
+	GasPrice             *string `json:"gas_price,omitempty"`
+	MaxFeePerGas         *string `json:"max_fee_per_gas,omitempty"`
+	MaxPriorityFeePerGas *string `json:"max_priority_fee_per_gas,omitempty"`
+}
+
 type RawAuction struct {
-	Id           string `json:"id"`
-	FeeRecipient string `json:"fee_recipient"`
-	ClosesAtUnix int64  `json:"closes_at_unix"`
-	ChainId      int64  `json:"chain_id"`
-	CreatedAt    int64  `json:"created_at_unix"`
-	Transaction  struct {
-		Data  string `json:"data"`
-		From  string `json:"from"`
-		Gas   int64  `json:"gas"`
-		Hash  string `json:"hash"`
-		To    string `json:"to"`
-		Value string `json:"value"`
-	} `json:"transaction"`
+	Id           string                `json:"id"`
+	FeeRecipient string                `json:"fee_recipient"`
+	ClosesAtUnix int64                 `json:"closes_at_unix"`
+	ChainId      int64                 `json:"chain_id"`
+	CreatedAt    int64                 `json:"created_at_unix"`
+	Transaction  RawAuctionTransaction `json:"transaction"`
 }
 
-type NewTransactionOptions struct {
-	Transaction  *types.Transaction
-	FeeRecipient common.Address
+// convert an auction from the feed
+func (r *RawAuction) Auction() (*Auction, error) {
+	transaction, err := r.Transaction.AuctionTransaction()
 
-	// optionally, a source
-	Source string
+	if err != nil {
+		return nil, err
+	}
 
-	// prevent reverts
-	PreventRevert bool
+	return &Auction{
+		Id:           r.Id,
+		FeeRecipient: r.FeeRecipient,
+		ChainId:      r.ChainId,
+		ClosesAt:     time.Unix(r.ClosesAtUnix, 0),
+		CreatedAt:    time.Unix(r.CreatedAt, 0),
+		Transaction:  transaction,
+	}, nil
+}
+
+func (r *RawAuctionTransaction) AuctionTransaction() (*AuctionTransaction, error) {
+	tx := &AuctionTransaction{
+		Hash: common.HexToHash(r.Hash),
+		Logs: r.Logs,
+	}
+
+	inferred := []Hint{HintHash}
+
+	if r.From != nil {
+		from := common.HexToAddress(*r.From)
+		tx.From = &from
+		inferred = append(inferred, HintFrom)
+	}
+
+	if r.To != nil {
+		// an empty destination is a contract creation
+		if *r.To != "" {
+			to := common.HexToAddress(*r.To)
+			tx.To = &to
+		}
+
+		inferred = append(inferred, HintTo)
+	}
 
-	// hints
-	Hints []string
+	if r.Value != nil {
+		value, ok := new(big.Int).SetString(*r.Value, 10)
 
-	// privacy profile
-	PrivacyProfile string
+		if !ok {
+			return nil, fmt.Errorf("failed to parse value: %s", *r.Value)
+		}
+
+		tx.Value = value
+		inferred = append(inferred, HintValue)
+	}
+
+	if r.Gas != nil {
+		gas := uint64(*r.Gas)
+		tx.Gas = &gas
+		inferred = append(inferred, HintGas)
+	}
+
+	if r.Data != nil {
+		tx.Data = common.FromHex(*r.Data)
+
+		if tx.Data == nil {
+			tx.Data = []byte{}
+		}
+
+		if len(tx.Data) >= 4 {
+			tx.FunctionSelector = tx.Data[:4]
+		}
+
+		inferred = append(inferred, HintCalldata)
+	} else if r.FunctionSelector != nil {
+		tx.FunctionSelector = common.FromHex(*r.FunctionSelector)
+		inferred = append(inferred, HintFunctionSelector)
+	}
+
+	if r.Logs != nil {
+		inferred = append(inferred, HintLogs)
+	}
+
+	fees := []struct {
+		name  string
+		value *string
+		field **big.Int
+	}{
+		{"gas price", r.GasPrice, &tx.GasPrice},
+		{"max fee per gas", r.MaxFeePerGas, &tx.GasFeeCap},
+		{"max priority fee per gas", r.MaxPriorityFeePerGas, &tx.GasTipCap},
+	}
+
+	for _, fee := range fees {
+		if fee.value == nil {
+			continue
+		}
+
+		value, ok := new(big.Int).SetString(*fee.value, 10)
+
+		if !ok {
+			return nil, fmt.Errorf("failed to parse %s: %s", fee.name, *fee.value)
+		}
+
+		*fee.field = value
+	}
+
+	tx.Hints = inferred
+
+	if len(r.Hints) > 0 {
+		tx.Hints = []Hint{HintHash}
+
+		for _, hint := range r.Hints {
+			if !supported(Hint(hint), tx.Hints) {
+				tx.Hints = append(tx.Hints, Hint(hint))
+			}
+		}
+	}
+
+	return tx, nil
 }
 
-func (p *PrivatePool) Send(options *NewTransactionOptions) error {
-	// send to the pool
-	type PoolSubmission struct {
-		// An array of transactions
-		Transactions []string `json:"transactions"`
+// the feed representation of an auction
+func (a *Auction) Raw() *RawAuction {
+	raw := &RawAuction{
+		Id:           a.Id,
+		FeeRecipient: a.FeeRecipient,
+		ClosesAtUnix: a.ClosesAt.Unix(),
+		ChainId:      a.ChainId,
+		CreatedAt:    a.CreatedAt.Unix(),
+	}
 
-		// The fee recipient
-		FeeRecipient string `json:"fee_recipient"`
+	if a.Transaction != nil {
+		raw.Transaction = *a.Transaction.Raw()
+	}
 
-		// Optional, a source tag
-		Source string `json:"source"`
+	return raw
+}
 
-		// Optional, a privacy profile
-		Privacy string `json:"privacy"`
+// the feed representation of an auction transaction
+func (t *AuctionTransaction) Raw() *RawAuctionTransaction {
+	raw := &RawAuctionTransaction{
+		Hash: t.Hash.String(),
+	}
 
-		// Optional, a list of hints, overrides the privacy profile
-		Hints []string `json:"hints"`
+	for _, hint := range t.Hints {
+		raw.Hints = append(raw.Hints, string(hint))
+	}
 
-		// Optional, a list of allowed bundles for this transaction
-		BundleTypes []string `json:"bundle_types"`
+	if t.From != nil {
+		from := t.From.String()
+		raw.From = &from
+	}
 
-		// Optional, a list of release targets
-		ReleaseTargets []string `json:"release_targets"`
+	if t.To != nil {
+		to := t.To.String()
+		raw.To = &to
+	} else if t.Reveals(HintTo) {
+		creation := ""
+		raw.To = &creation
+	}
 
-		// Optional, prevent reverts
-		PreventReverts bool `json:"prevent_reverts"`
+	if t.Value != nil {
+		value := t.Value.String()
+		raw.Value = &value
 	}
 
+	if t.Gas != nil {
+		gas := int64(*t.Gas)
+		raw.Gas = &gas
+	}
+
+	if t.Data != nil {
+		data := common.Bytes2Hex(t.Data)
+		raw.Data = &data
+	} else if t.FunctionSelector != nil {
+		selector := common.Bytes2Hex(t.FunctionSelector)
+		raw.FunctionSelector = &selector
+	}
+
+	raw.Logs = t.Logs
+
+	raw.GasPrice = decimalString(t.GasPrice)
+	raw.MaxFeePerGas = decimalString(t.GasFeeCap)
+	raw.MaxPriorityFeePerGas = decimalString(t.GasTipCap)
+
+	return raw
+}
+
+func decimalString(value *big.Int) *string {
+	if value == nil {
+		return nil
+	}
+
+	s := value.String()
+
+	return &s
+}
+
+type NewTransactionOptions struct {
+	Transaction  *types.Transaction
+	FeeRecipient common.Address
+
+	// optionally, a source
+	Source string
+
+	// prevent reverts
+	PreventRevert bool
+
+	// the fields shared with searchers, override the privacy profile
+	Hints []Hint
+
+	// a named set of hints
+	PrivacyProfile PrivacyProfile
+
+	// the bundles searchers may build around the transaction, all if empty
+	BundleTypes []BundleType
+
+	// where the transaction may be released, all if empty
+	ReleaseTargets []ReleaseTarget
+}
+
+// send a transaction to the private pool, returns the receipt of the submission
+func (p *PrivatePool) Send(ctx context.Context, options *NewTransactionOptions) (*SubmissionReceipt, error) {
 	signer := types.LatestSignerForChainID(options.Transaction.ChainId())
 	txFrom, err := signer.Sender(options.Transaction)
 
 	if err != nil {
-		return fmt.Errorf("failed to get transaction sender: %s", err)
+		return nil, fmt.Errorf("failed to get transaction sender: %s", err)
 	}
 
 	feeRecipient := txFrom.String()
@@ -125,10 +371,10 @@ func (p *PrivatePool) Send(options *NewTransactionOptions) error {
 	txBytes, err := options.Transaction.MarshalBinary()
 
 	if err != nil {
-		return fmt.Errorf("failed to marshal transaction: %s", err)
+		return nil, fmt.Errorf("failed to marshal transaction: %s", err)
 	}
 
-	submission := &PoolSubmission{
+	submission := &poolSubmission{
 		Transactions:   []string{common.Bytes2Hex(txBytes)},
 		FeeRecipient:   feeRecipient,
 		Source:         options.Source,
@@ -137,118 +383,119 @@ func (p *PrivatePool) Send(options *NewTransactionOptions) error {
 		PreventReverts: options.PreventRevert,
 	}
 
-	submissionBody, err := json.Marshal(submission)
+	privacy := PrivacySettings{Profile: options.PrivacyProfile, Hints: options.Hints}
 
-	if err != nil {
-		return fmt.Errorf("failed to marshal submission: %s", err)
+	if err := privacy.Validate(); err != nil {
+		return nil, err
 	}
 
-	client := &http.Client{}
+	if err := submission.setTargets(options.BundleTypes, options.ReleaseTargets); err != nil {
+		return nil, err
+	}
 
-	// send to the pool
-	req, err := http.NewRequest("POST", "https://mempool.merkle.io/transactions", bytes.NewBuffer(submissionBody))
+	return p.submit(ctx, submission)
+}
+
+func (w *WebsocketTransport) Auctions(ctx context.Context, apiKey string) (*Subscription[*Auction], error) {
+	conn, err := websocket.Dial("wss://mempool.merkle.io/stream/auctions?apiKey="+apiKey, "", "http://localhost/")
 
 	if err != nil {
-		return fmt.Errorf("failed to create request to pool: %s", err)
+		// the url carries the api key
+		return nil, fmt.Errorf("failed to connect to auctions: %s", redactApiKey(err.Error(), apiKey))
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	ctx, cancel := context.WithCancel(ctx)
 
-	if p.sdk.GetApiKey() != "" {
-		req.Header.Set("X-MBS-Key", p.sdk.GetApiKey())
-	}
+	sub := NewSubscription(make(chan *Auction), make(chan error))
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
 
-	res, err := client.Do(req)
+	// close the connection when the subscription is closed
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
 
-	if err != nil {
-		return fmt.Errorf("failed to send request to pool: %s", err)
-	}
+	// bound single frames too, a frame too large is skipped
+	conn.MaxPayloadBytes = w.maxMessageSize()
 
-	if res.StatusCode > 400 {
-		return fmt.Errorf("failed to send request to pool: code=%s", res.Status)
-	}
+	go func() {
+		defer sub.Close()
 
-	return nil
-}
+		// auctions can be too big for one frame and split across several
+		assembler := newMessageAssembler(w.maxMessageSize())
 
-func (p *PrivatePool) Auctions() (chan *Auction, chan error) {
-	auctions := make(chan *Auction)
-	errStream := make(chan error)
+		for {
+			var frame []byte
 
-	connect := func(auctionChannel chan *Auction, errChannel chan error) {
-		conn, err := websocket.Dial("wss://mempool.merkle.io/stream/auctions?apiKey="+p.sdk.GetApiKey(), "", "http://localhost/")
+			// a half-open connection goes quiet, give up on it so it's reopened
+			conn.SetReadDeadline(time.Now().Add(w.idleTimeout()))
 
-		if err != nil {
-			go func() {
-				errChannel <- err
-			}()
-			return
-		}
+			err := websocket.Message.Receive(conn, &frame)
 
-		go func() {
-			for {
-				var rawAuction RawAuction
-				var rawJSON string
-				var rawJSONTotal = ""
+			if errors.Is(err, websocket.ErrFrameTooLarge) {
+				assembler.reset()
+				sub.PushError(fmt.Errorf("skipping auction message: %w", ErrMessageTooLarge))
+				continue
+			}
 
-				// sometimes, the auctions are too big and split
-				// into multiple frames, we need to combine them
-				for {
-					err := websocket.Message.Receive(conn, &rawJSON)
+			if err != nil {
+				var netErr net.Error
 
-					if err != nil {
-						errChannel <- fmt.Errorf("failed to receive message: %s", err)
-						return
-					}
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					err = fmt.Errorf("no message for %s", w.idleTimeout())
+				}
 
-					rawJSONTotal += rawJSON
+				if ctx.Err() == nil {
+					sub.PushError(fmt.Errorf("failed to receive message: %s", err))
+				}
+				return
+			}
 
-					err = json.Unmarshal([]byte(rawJSONTotal), &rawAuction)
+			messages, err := assembler.feed(frame)
 
-					if err == nil {
-						break
-					}
-				}
+			if err != nil {
+				sub.PushError(fmt.Errorf("skipping auction message: %w", err))
+			}
 
-				value := new(big.Int)
-				value, ok := value.SetString(rawAuction.Transaction.Value, 10)
+			for _, message := range messages {
+				var rawAuction RawAuction
 
-				if !ok {
-					errChannel <- fmt.Errorf("failed to parse value: %s", rawAuction.Transaction.Value)
-					return
+				if err := json.Unmarshal(message, &rawAuction); err != nil {
+					sub.PushError(fmt.Errorf("skipping auction message: %s", err))
+					continue
 				}
 
-				data := common.Hex2Bytes(rawAuction.Transaction.Data)
-
-				auction := Auction{
-					Id:           rawAuction.Id,
-					FeeRecipient: rawAuction.FeeRecipient,
-					ChainId:      rawAuction.ChainId,
-					ClosesAt:     time.Unix(rawAuction.ClosesAtUnix, 0),
-					CreatedAt:    time.Unix(rawAuction.CreatedAt, 0),
-					Transaction: &AuctionTransaction{
-						Hash:  common.HexToHash(rawAuction.Transaction.Hash),
-						From:  common.HexToAddress(rawAuction.Transaction.From),
-						To:    common.HexToAddress(rawAuction.Transaction.To),
-						Value: value,
-						Data:  data,
-						Gas:   uint64(rawAuction.Transaction.Gas),
-					},
-					// keep track of the connection for bids
-					Connection: conn,
+				// a part of a message cut short, see messageAssembler
+				if rawAuction.Id == "" {
+					sub.PushError(fmt.Errorf("skipping auction message: %w: no auction id", ErrMalformedFrame))
+					continue
 				}
 
-				auctionChannel <- &auction
-			}
-		}()
-	}
+				auction, err := rawAuction.Auction()
+
+				if err != nil {
+					sub.PushError(fmt.Errorf("skipping auction message: %s", err))
+					continue
+				}
 
-	connect(auctions, errStream)
+				// keep track of the connection for bids
+				auction.Connection = conn
 
-	return auctions, errStream
+				if !sub.Push(auction) {
+					return
+				}
+			}
+		}
+	}()
+
+	return sub, nil
 }
 
-func (p *PrivatePool) SendBid(txHash string, tx *types.Transaction) (string, error) {
+// send a bid for the auction of a transaction hash, returns the bid id
+func (p *PrivatePool) SendBid(ctx context.Context, txHash string, tx *types.Transaction) (string, error) {
 	bin, err := tx.MarshalBinary()
 
 	if err != nil {
@@ -257,10 +504,12 @@ func (p *PrivatePool) SendBid(txHash string, tx *types.Transaction) (string, err
 
 	hex := common.Bytes2Hex(bin)
 
-	return SendRawBid(txHash, []string{hex})
+	return sendRawBid(ctx, txHash, []string{hex})
 }
 
-func (a *Auction) SendBid(tx types.Transaction) (string, error) {
+// send a bid for the auction, returns the bid id. The bid is rejected with
+// ErrAuctionClosed when it's sent after the deadline of the auction
+func (a *Auction) SendBid(ctx context.Context, tx *types.Transaction) (string, error) {
 	bin, err := tx.MarshalBinary()
 
 	if err != nil {
@@ -269,7 +518,7 @@ func (a *Auction) SendBid(tx types.Transaction) (string, error) {
 
 	hex := common.Bytes2Hex(bin)
 
-	return SendRawBid(a.Transaction.Hash.String(), []string{hex})
+	return a.SendRawBid(ctx, []string{hex})
 }
 
 type RelaySubmitRequest struct {
@@ -284,7 +533,13 @@ type BundleParams struct {
 }
 
 func SendRawBid(hash string, txs []string) (string, error) {
-	// send a request to https://mempool.merkle.io/relay
+	return sendRawBid(context.Background(), hash, txs)
+}
+
+// the relay of the auction bids
+const relayUrl = poolApiUrl + "/relay"
+
+func sendRawBid(ctx context.Context, hash string, txs []string) (string, error) {
 	payload := &RelaySubmitRequest{
 		Method: "eth_sendBundle",
 		Params: []BundleParams{
@@ -302,30 +557,46 @@ func SendRawBid(hash string, txs []string) (string, error) {
 		return "", fmt.Errorf("failed to marshal payload: %s", err)
 	}
 
-	res, err := http.Post("https://mempool.merkle.io/relay", "application/json", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", relayUrl, bytes.NewBuffer(body))
 
 	if err != nil {
-		return "", fmt.Errorf("failed to send request: %s", err)
+		return "", fmt.Errorf("failed to create request: %s", err)
 	}
 
-	if res.StatusCode > 400 {
-		return "", fmt.Errorf("failed to send request: code=%s", res.Status)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := doRequest(req)
+
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %s", err)
 	}
 
 	defer res.Body.Close()
 
-	// decode the response
-	var resBody map[string]interface{}
-
-	err = json.NewDecoder(res.Body).Decode(&resBody)
+	bodyRead, err := io.ReadAll(res.Body)
 
 	if err != nil {
+		return "", fmt.Errorf("failed to read response: %s", err)
+	}
+
+	if res.StatusCode >= 400 {
+		return "", &APIError{URL: relayUrl, StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
+	}
+
+	var rpcRes rpcResponse
+
+	if err := json.Unmarshal(bodyRead, &rpcRes); err != nil {
 		return "", fmt.Errorf("failed to unmarshal response: %s", err)
 	}
 
-	bidId, ok := resBody["result"].(string)
+	// the relay refused the bid
+	if rpcRes.Error != nil {
+		return "", rpcRes.Error
+	}
+
+	var bidId string
 
-	if !ok {
+	if err := json.Unmarshal(rpcRes.Result, &bidId); err != nil || bidId == "" {
 		return "", fmt.Errorf("failed to get bid id")
 	}
 
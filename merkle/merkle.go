@@ -3,6 +3,8 @@ package merkle
 type MerkleSDK struct {
 	ApiKey string
 
+	transport Transport
+
 	transactions *TransactionStream
 	pool         *PrivatePool
 	builder      *BuilderSDK
@@ -23,6 +25,19 @@ func (m *MerkleSDK) GetApiKey() string {
 	return m.ApiKey
 }
 
+// select the transport used by the streams
+func (m *MerkleSDK) SetTransport(transport Transport) {
+	m.transport = transport
+}
+
+// get the transport used by the streams, websockets by default
+func (m *MerkleSDK) GetTransport() Transport {
+	if m.transport == nil {
+		m.transport = NewWebsocketTransport()
+	}
+	return m.transport
+}
+
 func (m *MerkleSDK) Pool() *PrivatePool {
 	if m.pool == nil {
 		m.pool = NewPrivatePool(m)
@@ -0,0 +1,91 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// the private pool api
+const poolApiUrl = "https://mempool.merkle.io"
+
+// send a request to the private pool api and decode the response into resp,
+// a nil body sends no body. Error responses are returned as *APIError
+func (p *PrivatePool) poolRequest(ctx context.Context, method string, url string, body interface{}, resp interface{}) error {
+	var reader io.Reader
+
+	if body != nil {
+		bodyBytes, err := json.Marshal(body)
+
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %s", err)
+		}
+
+		reader = bytes.NewReader(bodyBytes)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+
+	if err != nil {
+		return fmt.Errorf("failed to create request to pool: %s", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if p.sdk.GetApiKey() != "" {
+		req.Header.Set("X-MBS-Key", p.sdk.GetApiKey())
+	}
+
+	res, err := doRequest(req)
+
+	if err != nil {
+		return fmt.Errorf("failed to send request to pool: %s", err)
+	}
+
+	defer res.Body.Close()
+
+	bodyRead, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return fmt.Errorf("failed to read pool response: %s", err)
+	}
+
+	if res.StatusCode >= 400 {
+		return &APIError{URL: url, StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
+	}
+
+	if resp == nil || len(bytes.TrimSpace(bodyRead)) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(bodyRead, resp); err != nil {
+		return fmt.Errorf("failed to decode pool response: %s", err)
+	}
+
+	return nil
+}
+
+// the reason of an error response, {"error": ...} or
+// {"message": ...}, the body if it isn't json
+func errorReason(body string) string {
+	reason := struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}{}
+
+	json.Unmarshal([]byte(body), &reason)
+
+	if reason.Error != "" {
+		return reason.Error
+	}
+
+	if reason.Message != "" {
+		return reason.Message
+	}
+
+	return strings.TrimSpace(body)
+}
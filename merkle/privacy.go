@@ -0,0 +1,207 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// a transaction field shared with searchers in auctions
+type Hint string
+
+const (
+	// the transaction hash, always shared
+	HintHash Hint = "hash"
+
+	HintFrom  Hint = "from"
+	HintTo    Hint = "to"
+	HintValue Hint = "value"
+	HintGas   Hint = "gas"
+
+	// the full calldata
+	HintCalldata Hint = "calldata"
+
+	// the first 4 bytes of the calldata
+	HintFunctionSelector Hint = "function_selector"
+
+	// the logs the transaction emits when simulated
+	HintLogs Hint = "logs"
+)
+
+// a named set of hints
+type PrivacyProfile string
+
+const (
+	// only the hash, the smallest refunds
+	PrivacyProfileMaxPrivacy PrivacyProfile = "max_privacy"
+
+	// the destination, function selector and logs
+	PrivacyProfileDefault PrivacyProfile = "default"
+
+	// everything, the largest refunds
+	PrivacyProfileMaxRefund PrivacyProfile = "max_refund"
+)
+
+// the hints of every profile
+var privacyProfileHints = map[PrivacyProfile][]Hint{
+	PrivacyProfileMaxPrivacy: {HintHash},
+	PrivacyProfileDefault:    {HintHash, HintTo, HintFunctionSelector, HintLogs},
+	PrivacyProfileMaxRefund:  {HintHash, HintFrom, HintTo, HintValue, HintGas, HintCalldata, HintLogs},
+}
+
+// every supported hint
+var supportedHints = []Hint{
+	HintHash,
+	HintFrom,
+	HintTo,
+	HintValue,
+	HintGas,
+	HintCalldata,
+	HintFunctionSelector,
+	HintLogs,
+}
+
+var (
+	ErrUnknownHint           = errors.New("unknown hint")
+	ErrUnknownPrivacyProfile = errors.New("unknown privacy profile")
+)
+
+// what a transaction shares with searchers. Hints override the profile,
+// the pool's default profile applies if neither is set
+type PrivacySettings struct {
+	Profile PrivacyProfile
+	Hints   []Hint
+}
+
+// check the hints and the profile are known. Both may be set, the hints
+// override the profile
+func (s PrivacySettings) Validate() error {
+	if _, ok := privacyProfileHints[s.Profile]; s.Profile != "" && !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownPrivacyProfile, s.Profile)
+	}
+
+	for _, hint := range s.Hints {
+		if !supported(hint, supportedHints) {
+			return fmt.Errorf("%w: %s", ErrUnknownHint, hint)
+		}
+	}
+
+	return nil
+}
+
+// the hints that apply, the hash is always shared
+func (s PrivacySettings) Effective() []Hint {
+	hints := s.Hints
+
+	if len(hints) == 0 {
+		profile := s.Profile
+
+		if profile == "" {
+			profile = PrivacyProfileDefault
+		}
+
+		hints = privacyProfileHints[profile]
+	}
+
+	effective := []Hint{HintHash}
+
+	for _, hint := range hints {
+		if !supported(hint, effective) {
+			effective = append(effective, hint)
+		}
+	}
+
+	return effective
+}
+
+// whether a hint applies, like AuctionTransaction.Reveals the calldata
+// reveals the function selector
+func (s PrivacySettings) Reveals(hint Hint) bool {
+	effective := s.Effective()
+
+	if hint == HintFunctionSelector && supported(HintCalldata, effective) {
+		return true
+	}
+
+	return supported(hint, effective)
+}
+
+// the AuctionTransaction fields searchers see in auctions, "FunctionSelector"
+// without "Data" when only the function selector is shared
+func (s PrivacySettings) Describe() []string {
+	fields := []string{"Hash"}
+
+	if s.Reveals(HintFrom) {
+		fields = append(fields, "From")
+	}
+
+	if s.Reveals(HintTo) {
+		fields = append(fields, "To")
+	}
+
+	if s.Reveals(HintValue) {
+		fields = append(fields, "Value")
+	}
+
+	if s.Reveals(HintCalldata) {
+		fields = append(fields, "Data")
+	}
+
+	if s.Reveals(HintFunctionSelector) {
+		fields = append(fields, "FunctionSelector")
+	}
+
+	if s.Reveals(HintGas) {
+		fields = append(fields, "Gas")
+	}
+
+	if s.Reveals(HintLogs) {
+		fields = append(fields, "Logs")
+	}
+
+	return fields
+}
+
+// composes privacy settings, errors are reported by Build
+type PrivacyBuilder struct {
+	settings PrivacySettings
+}
+
+func NewPrivacyBuilder() *PrivacyBuilder {
+	return &PrivacyBuilder{}
+}
+
+// use a named profile
+func (b *PrivacyBuilder) Profile(profile PrivacyProfile) *PrivacyBuilder {
+	b.settings.Profile = profile
+	return b
+}
+
+// share some fields, duplicates are ignored
+func (b *PrivacyBuilder) Hint(hints ...Hint) *PrivacyBuilder {
+	for _, hint := range hints {
+		if !supported(hint, b.settings.Hints) {
+			b.settings.Hints = append(b.settings.Hints, hint)
+		}
+	}
+
+	return b
+}
+
+// the settings, the full calldata makes the function selector redundant so it's dropped
+func (b *PrivacyBuilder) Build() (PrivacySettings, error) {
+	settings := PrivacySettings{Profile: b.settings.Profile}
+
+	for _, hint := range b.settings.Hints {
+		if hint == HintFunctionSelector && supported(HintCalldata, b.settings.Hints) {
+			continue
+		}
+
+		settings.Hints = append(settings.Hints, hint)
+	}
+
+	if err := settings.Validate(); err != nil {
+		return PrivacySettings{}, err
+	}
+
+	return settings, nil
+}
@@ -0,0 +1,60 @@
+package merkle
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestPrivacySettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  PrivacySettings
+		err       error
+		effective []Hint
+	}{
+		{"pool default", PrivacySettings{}, nil, []Hint{HintHash, HintTo, HintFunctionSelector, HintLogs}},
+		{"profile", PrivacySettings{Profile: PrivacyProfileMaxPrivacy}, nil, []Hint{HintHash}},
+		{"hints", PrivacySettings{Hints: []Hint{HintValue}}, nil, []Hint{HintHash, HintValue}},
+		{"hints override the profile", PrivacySettings{Profile: PrivacyProfileMaxRefund, Hints: []Hint{HintTo}}, nil, []Hint{HintHash, HintTo}},
+		{"unknown profile", PrivacySettings{Profile: "public"}, ErrUnknownPrivacyProfile, nil},
+		{"unknown hint", PrivacySettings{Hints: []Hint{"nonce"}}, ErrUnknownHint, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.settings.Validate()
+
+			if !errors.Is(err, test.err) {
+				t.Fatalf("got error %v, want %v", err, test.err)
+			}
+
+			if test.err != nil {
+				return
+			}
+
+			if got := test.settings.Effective(); !reflect.DeepEqual(got, test.effective) {
+				t.Fatalf("got hints %v, want %v", got, test.effective)
+			}
+		})
+	}
+}
+
+func TestPrivacyBuilderDropsRedundantSelector(t *testing.T) {
+	settings, err := NewPrivacyBuilder().
+		Profile(PrivacyProfileDefault).
+		Hint(HintFunctionSelector, HintCalldata, HintCalldata).
+		Build()
+
+	if err != nil {
+		t.Fatalf("failed to build: %s", err)
+	}
+
+	if !reflect.DeepEqual(settings.Hints, []Hint{HintCalldata}) {
+		t.Fatalf("got hints %v", settings.Hints)
+	}
+
+	if got := settings.Describe(); !reflect.DeepEqual(got, []string{"Hash", "Data", "FunctionSelector"}) {
+		t.Fatalf("got fields %v", got)
+	}
+}
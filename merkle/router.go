@@ -0,0 +1,380 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// routes can't be added once the router runs, and it only runs once
+var ErrRouterStarted = errors.New("auction router already started")
+
+// which auctions a strategy is interested in. Empty fields match every
+// auction, filters on a field the transaction doesn't reveal never match
+type AuctionFilter struct {
+	ChainIds []MerkleChainId
+
+	// the destination of the transaction, needs the to hint
+	Destinations []common.Address
+
+	// the first 4 bytes of the calldata, needs the calldata or the
+	// function selector hint
+	Selectors [][]byte
+
+	// the minimum value of the transaction, needs the value hint
+	MinValue *big.Int
+
+	// skip auctions closing sooner than this
+	MinTimeToClose time.Duration
+}
+
+// a filter compiled for lookups
+type auctionMatcher struct {
+	chainIds       map[int64]bool
+	destinations   map[common.Address]bool
+	selectors      [][]byte
+	minValue       *big.Int
+	minTimeToClose time.Duration
+}
+
+func (f *AuctionFilter) matcher() *auctionMatcher {
+	m := &auctionMatcher{}
+
+	if f == nil {
+		return m
+	}
+
+	if len(f.ChainIds) > 0 {
+		m.chainIds = map[int64]bool{}
+
+		for _, chainId := range f.ChainIds {
+			m.chainIds[int64(chainId)] = true
+		}
+	}
+
+	if len(f.Destinations) > 0 {
+		m.destinations = map[common.Address]bool{}
+
+		for _, destination := range f.Destinations {
+			m.destinations[destination] = true
+		}
+	}
+
+	for _, selector := range f.Selectors {
+		if len(selector) > 4 {
+			selector = selector[:4]
+		}
+
+		m.selectors = append(m.selectors, common.CopyBytes(selector))
+	}
+
+	if f.MinValue != nil {
+		m.minValue = new(big.Int).Set(f.MinValue)
+	}
+
+	m.minTimeToClose = f.MinTimeToClose
+
+	return m
+}
+
+// whether an auction matches the filter at a given time
+func (f *AuctionFilter) Matches(auction *Auction, now time.Time) bool {
+	return f.matcher().matches(auction, now)
+}
+
+func (m *auctionMatcher) matches(auction *Auction, now time.Time) bool {
+	if m.chainIds != nil && !m.chainIds[auction.ChainId] {
+		return false
+	}
+
+	if m.minTimeToClose > 0 && auction.ClosesAt.Sub(now) < m.minTimeToClose {
+		return false
+	}
+
+	if m.destinations == nil && m.selectors == nil && m.minValue == nil {
+		return true
+	}
+
+	tx := auction.Transaction
+
+	if tx == nil {
+		return false
+	}
+
+	if m.destinations != nil && (tx.To == nil || !m.destinations[*tx.To]) {
+		return false
+	}
+
+	if m.selectors != nil {
+		selector := tx.FunctionSelector
+
+		if selector == nil && len(tx.Data) >= 4 {
+			selector = tx.Data[:4]
+		}
+
+		if selector == nil || !matchesSelector(m.selectors, selector) {
+			return false
+		}
+	}
+
+	if m.minValue != nil && (tx.Value == nil || tx.Value.Cmp(m.minValue) < 0) {
+		return false
+	}
+
+	return true
+}
+
+func matchesSelector(selectors [][]byte, selector []byte) bool {
+	for _, s := range selectors {
+		if bytes.Equal(s, selector) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// handles the auctions matching a route
+type AuctionHandler func(ctx context.Context, auction *Auction)
+
+type RouteOptions struct {
+	// auctions queued for a slow handler, the oldest are dropped
+	// when it's full. Defaults to 64
+	BufferSize int
+}
+
+func (o *RouteOptions) withDefaults() RouteOptions {
+	opts := RouteOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 64
+	}
+
+	return opts
+}
+
+// counters of a route
+type RouteStats struct {
+	// auctions handed to the handler
+	Handled uint64
+
+	// auctions dropped because the handler was too slow
+	Dropped uint64
+
+	// auctions that closed while queued for the handler
+	Expired uint64
+}
+
+type auctionRoute struct {
+	name    string
+	matcher *auctionMatcher
+	handler AuctionHandler
+	queue   chan *Auction
+
+	handled atomic.Uint64
+	dropped atomic.Uint64
+	expired atomic.Uint64
+}
+
+// queue an auction without blocking the router, the oldest
+// auction is dropped if the handler is behind
+func (r *auctionRoute) offer(auction *Auction) {
+	for {
+		select {
+		case r.queue <- auction:
+			return
+		default:
+		}
+
+		select {
+		case <-r.queue:
+			r.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+func (r *auctionRoute) run(ctx context.Context) {
+	for {
+		select {
+		case auction, ok := <-r.queue:
+			if !ok {
+				return
+			}
+
+			// it may have closed while waiting
+			if !auction.ClosesAt.After(time.Now()) {
+				r.expired.Add(1)
+				continue
+			}
+
+			r.handled.Add(1)
+			r.handler(ctx, auction)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatches the auctions of one subscription to several handlers. Every
+// filter is evaluated once per auction, and each handler runs in its own
+// goroutine so a slow strategy doesn't hold back the others
+type AuctionRouter struct {
+	mu      sync.Mutex
+	routes  []*auctionRoute
+	running bool
+
+	received atomic.Uint64
+	expired  atomic.Uint64
+
+	// called with the errors of the subscription, optional
+	OnError func(err error)
+}
+
+func NewAuctionRouter() *AuctionRouter {
+	return &AuctionRouter{}
+}
+
+// register a handler for the auctions matching a filter, the name identifies
+// the route in the stats. Routes must be registered before Run is called
+func (r *AuctionRouter) Handle(name string, filter *AuctionFilter, handler AuctionHandler, options *RouteOptions) error {
+	opts := options.withDefaults()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.running {
+		return ErrRouterStarted
+	}
+
+	r.routes = append(r.routes, &auctionRoute{
+		name:    name,
+		matcher: filter.matcher(),
+		handler: handler,
+		queue:   make(chan *Auction, opts.BufferSize),
+	})
+
+	return nil
+}
+
+// the number of auctions read from the subscription
+func (r *AuctionRouter) Received() uint64 {
+	return r.received.Load()
+}
+
+// the number of auctions dropped because they were already closed
+func (r *AuctionRouter) Expired() uint64 {
+	return r.expired.Load()
+}
+
+// the counters of every route, by name
+func (r *AuctionRouter) Stats() map[string]RouteStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats := map[string]RouteStats{}
+
+	for _, route := range r.routes {
+		s := stats[route.name]
+
+		s.Handled += route.handled.Load()
+		s.Dropped += route.dropped.Load()
+		s.Expired += route.expired.Load()
+
+		stats[route.name] = s
+	}
+
+	return stats
+}
+
+func (r *AuctionRouter) streamError(err error) {
+	if r.OnError != nil {
+		r.OnError(err)
+	}
+}
+
+// dispatch the auctions of a subscription until the context is done or the
+// subscription ends, then wait for the handlers to return. Queued auctions
+// are still handled when the subscription ends, not when the context is done
+func (r *AuctionRouter) Run(ctx context.Context, sub *Subscription[*Auction]) error {
+	r.mu.Lock()
+
+	if r.running {
+		r.mu.Unlock()
+		return ErrRouterStarted
+	}
+
+	r.running = true
+	routes := r.routes
+	r.mu.Unlock()
+
+	wg := sync.WaitGroup{}
+
+	for _, route := range routes {
+		wg.Add(1)
+
+		go func(route *auctionRoute) {
+			defer wg.Done()
+			route.run(ctx)
+		}(route)
+	}
+
+	err := r.dispatch(ctx, sub, routes)
+
+	for _, route := range routes {
+		close(route.queue)
+	}
+
+	wg.Wait()
+
+	return err
+}
+
+func (r *AuctionRouter) dispatch(ctx context.Context, sub *Subscription[*Auction], routes []*auctionRoute) error {
+	errs := sub.Err()
+
+	for {
+		select {
+		case auction, ok := <-sub.Items():
+			if !ok {
+				return nil
+			}
+
+			r.received.Add(1)
+
+			now := time.Now()
+
+			if !auction.ClosesAt.After(now) {
+				r.expired.Add(1)
+				continue
+			}
+
+			for _, route := range routes {
+				if route.matcher.matches(auction, now) {
+					route.offer(auction)
+				}
+			}
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+
+			r.streamError(err)
+		case <-sub.Done():
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package merkle
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+type rpcRequest struct {
+	Jsonrpc string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Id      int         `json:"id"`
+}
+
+type rpcResponse struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *RPCError       `json:"error"`
+}
+
+// keep the api key of a url out of errors
+func redactApiKey(s string, apiKey string) string {
+	if apiKey == "" {
+		return s
+	}
+
+	return strings.ReplaceAll(s, apiKey, "<api-key>")
+}
+
+// post a json-rpc request (or batch) and decode the response body. The api
+// key in the url is redacted from the errors
+func postRpc(ctx context.Context, url string, apiKey string, body interface{}, resp interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling body: %s", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(bodyBytes))
+
+	if err != nil {
+		return fmt.Errorf("error creating request: %s", redactApiKey(err.Error(), apiKey))
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := doRequest(req)
+
+	if err != nil {
+		return fmt.Errorf("error sending request: %s", redactApiKey(err.Error(), apiKey))
+	}
+
+	defer res.Body.Close()
+
+	bodyRead, err := io.ReadAll(res.Body)
+
+	if err != nil {
+		return fmt.Errorf("error reading response: %s", err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return &APIError{URL: redactApiKey(url, apiKey), StatusCode: res.StatusCode, Status: res.Status, Body: string(bodyRead)}
+	}
+
+	if err := json.Unmarshal(bodyRead, resp); err != nil {
+		return fmt.Errorf("error decoding response: %s", err)
+	}
+
+	return nil
+}
+
+// the transaction hash of an eth_sendRawTransaction response
+func (r *rpcResponse) txHash() (common.Hash, error) {
+	if r.Error != nil {
+		return common.Hash{}, r.Error
+	}
+
+	var hash common.Hash
+
+	if err := json.Unmarshal(r.Result, &hash); err != nil {
+		return common.Hash{}, fmt.Errorf("error decoding transaction hash: %s", err)
+	}
+
+	return hash, nil
+}
@@ -0,0 +1,57 @@
+package merkle
+
+import (
+	"crypto/ecdsa"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signs transactions, e.g. with a private key, a keystore or a remote signer
+type Signer interface {
+	// the address of the signing key
+	Address() common.Address
+
+	// sign a transaction for a chain
+	SignTx(chainId *big.Int, tx *types.Transaction) (*types.Transaction, error)
+
+	// sign a message like personal_sign (EIP-191), returns the 65 bytes signature
+	SignMessage(message []byte) ([]byte, error)
+}
+
+type privateKeySigner struct {
+	key     *ecdsa.PrivateKey
+	address common.Address
+}
+
+// a signer holding a private key in memory
+func NewPrivateKeySigner(key *ecdsa.PrivateKey) Signer {
+	return &privateKeySigner{
+		key:     key,
+		address: crypto.PubkeyToAddress(key.PublicKey),
+	}
+}
+
+func (s *privateKeySigner) Address() common.Address {
+	return s.address
+}
+
+func (s *privateKeySigner) SignTx(chainId *big.Int, tx *types.Transaction) (*types.Transaction, error) {
+	return types.SignTx(tx, types.LatestSignerForChainID(chainId), s.key)
+}
+
+func (s *privateKeySigner) SignMessage(message []byte) ([]byte, error) {
+	signature, err := crypto.Sign(accounts.TextHash(message), s.key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the recovery id as expected by ecrecover
+	signature[crypto.RecoveryIDOffset] += 27
+
+	return signature, nil
+}
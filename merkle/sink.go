@@ -0,0 +1,267 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// a flat record written to a sink, keys are column names
+type Record map[string]interface{}
+
+// a destination for streamed items, e.g. a file
+type Sink interface {
+	// write a batch of records
+	Write(records []Record) error
+
+	// flush and release the sink
+	Close() error
+}
+
+type SinkOptions struct {
+	// number of records written at once, defaults to 100
+	BatchSize int
+
+	// maximum time a record waits before being written, defaults to 1 second
+	FlushInterval time.Duration
+
+	// number of records queued for the sink before new ones
+	// are dropped, defaults to 10000
+	BufferSize int
+}
+
+func (o *SinkOptions) withDefaults() SinkOptions {
+	opts := SinkOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 1 * time.Second
+	}
+
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = 10000
+	}
+
+	return opts
+}
+
+// attach a sink to a subscription. Items are passed through to the returned
+// subscription and written to the sink in batches in the background. Sink errors
+// are reported on the returned subscription's error channel, the oldest are dropped
+// if they aren't read. Closing the returned subscription closes the original one,
+// flushes and closes the sink.
+func AttachSink[T any](sub *Subscription[T], sink Sink, record func(T) Record, options *SinkOptions) *Subscription[T] {
+	opts := options.withDefaults()
+
+	out := NewSubscription(make(chan T), make(chan error, 16))
+
+	queue := make(chan Record, opts.BufferSize)
+	flushed := make(chan error, 1)
+
+	// writer, batches the records
+	go func() {
+		batch := make([]Record, 0, opts.BatchSize)
+
+		ticker := time.NewTicker(opts.FlushInterval)
+		defer ticker.Stop()
+
+		write := func() {
+			if len(batch) == 0 {
+				return
+			}
+
+			if err := sink.Write(batch); err != nil {
+				out.report(fmt.Errorf("error writing to sink: %s", err))
+			}
+
+			batch = make([]Record, 0, opts.BatchSize)
+		}
+
+		for {
+			select {
+			case r, ok := <-queue:
+				if !ok {
+					write()
+					flushed <- sink.Close()
+					return
+				}
+
+				batch = append(batch, r)
+
+				if len(batch) >= opts.BatchSize {
+					write()
+				}
+			case <-ticker.C:
+				write()
+			}
+		}
+	}()
+
+	// reader, passes items through and queues them for the sink
+	go func() {
+		defer close(queue)
+
+		errs := sub.Err()
+
+		for {
+			select {
+			case item, ok := <-sub.Items():
+				if !ok {
+					go out.Close()
+					return
+				}
+
+				select {
+				case queue <- record(item):
+				default:
+					out.report(fmt.Errorf("sink buffer is full, dropping record"))
+				}
+
+				if !out.Push(item) {
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+
+				out.report(err)
+			case <-sub.Done():
+				go out.Close()
+				return
+			case <-out.Done():
+				return
+			}
+		}
+	}()
+
+	out.OnClose(func() error {
+		sub.Close()
+
+		if err := <-flushed; err != nil {
+			return fmt.Errorf("error closing sink: %s", err)
+		}
+
+		return nil
+	})
+
+	return out
+}
+
+// the default record of a streamed transaction
+func TransactionRecord(tx *types.Transaction) Record {
+	record := Record{
+		"hash":     tx.Hash().String(),
+		"chain_id": tx.ChainId().String(),
+		"type":     int(tx.Type()),
+		"nonce":    tx.Nonce(),
+		"to":       "",
+		"value":    tx.Value().String(),
+		"gas":      tx.Gas(),
+		"data":     "0x" + common.Bytes2Hex(tx.Data()),
+		"seen_at":  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+
+	if tx.To() != nil {
+		record["to"] = tx.To().String()
+	}
+
+	if from, err := types.LatestSignerForChainID(tx.ChainId()).Sender(tx); err == nil {
+		record["from"] = from.String()
+	} else {
+		record["from"] = ""
+	}
+
+	if tx.Type() == types.LegacyTxType || tx.Type() == types.AccessListTxType {
+		record["gas_price"] = tx.GasPrice().String()
+	} else {
+		record["gas_fee_cap"] = tx.GasFeeCap().String()
+		record["gas_tip_cap"] = tx.GasTipCap().String()
+	}
+
+	return record
+}
+
+// the default record of an auction
+func AuctionRecord(auction *Auction) Record {
+	record := Record{
+		"id":            auction.Id,
+		"fee_recipient": auction.FeeRecipient,
+		"chain_id":      auction.ChainId,
+		"closes_at":     auction.ClosesAt.UTC().Format(time.RFC3339Nano),
+		"created_at":    auction.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+
+	if tx := auction.Transaction; tx != nil {
+		record["hash"] = tx.Hash.String()
+
+		// hidden fields are left empty
+		if tx.From != nil {
+			record["from"] = tx.From.String()
+		}
+
+		if tx.To != nil {
+			record["to"] = tx.To.String()
+		}
+
+		if tx.Data != nil {
+			record["data"] = "0x" + common.Bytes2Hex(tx.Data)
+		}
+
+		if tx.FunctionSelector != nil {
+			record["function_selector"] = "0x" + common.Bytes2Hex(tx.FunctionSelector)
+		}
+
+		if tx.Gas != nil {
+			record["gas"] = *tx.Gas
+		}
+
+		if tx.Value != nil {
+			record["value"] = tx.Value.String()
+		}
+
+		hints := []string{}
+
+		for _, hint := range tx.Hints {
+			hints = append(hints, string(hint))
+		}
+
+		record["hints"] = strings.Join(hints, ",")
+	}
+
+	return record
+}
+
+// columns of the default transaction record, in a sensible order for csv files
+var TransactionColumns = []string{"seen_at", "hash", "chain_id", "type", "from", "to", "nonce", "value", "gas", "gas_price", "gas_fee_cap", "gas_tip_cap", "data"}
+
+// columns of the default auction record, in a sensible order for csv files
+var AuctionColumns = []string{"created_at", "closes_at", "id", "chain_id", "fee_recipient", "hash", "hints", "from", "to", "value", "gas", "function_selector", "data"}
+
+// stream transactions into a sink, closing the subscription stops the stream
+func (t *TransactionStream) StreamToSink(chainId MerkleChainId, sink Sink, options *SinkOptions) (*Subscription[*types.Transaction], error) {
+	sub, err := t.Subscribe(context.Background(), chainId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return AttachSink(sub, sink, TransactionRecord, options), nil
+}
+
+// stream auctions into a sink
+func (p *PrivatePool) AuctionsToSink(sink Sink, options *SinkOptions) *Subscription[*Auction] {
+	return AttachSink(p.Auctions(context.Background(), nil).Subscription, sink, AuctionRecord, options)
+}
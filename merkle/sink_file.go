@@ -0,0 +1,327 @@
+package merkle
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FileSinkOptions struct {
+	// path of the file, when rotation is enabled, a timestamp
+	// is added before the extension of every file
+	Path string
+
+	// rotate once a file reaches this many bytes (before compression), 0 to disable
+	MaxSize int64
+
+	// rotate once a file has been open this long, 0 to disable. The age is
+	// checked when records are written, an idle file stays open until the
+	// next record, which starts a new file
+	MaxAge time.Duration
+
+	// gzip the files, adds a .gz extension
+	Compress bool
+}
+
+// a file that rotates by size or age, optionally gzipped
+type rotatingFile struct {
+	options FileSinkOptions
+
+	// called with every new file, e.g. to write a header. Not called when
+	// appending to a file that isn't empty
+	onOpen func(w io.Writer) error
+
+	file     *os.File
+	gz       *gzip.Writer
+	buf      *bufio.Writer
+	size     int64
+	openedAt time.Time
+}
+
+func (r *rotatingFile) rotates() bool {
+	return r.options.MaxSize > 0 || r.options.MaxAge > 0
+}
+
+func (r *rotatingFile) name() string {
+	name := r.options.Path
+
+	if r.rotates() {
+		ext := filepath.Ext(name)
+		name = fmt.Sprintf("%s-%s%s", strings.TrimSuffix(name, ext), time.Now().UTC().Format("20060102T150405.000000000"), ext)
+	}
+
+	if r.options.Compress {
+		name += ".gz"
+	}
+
+	return name
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.name(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+
+	if err != nil {
+		return fmt.Errorf("error opening file: %s", err)
+	}
+
+	info, err := file.Stat()
+
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("error opening file: %s", err)
+	}
+
+	r.file = file
+	r.size = 0
+	r.openedAt = time.Now()
+
+	var w io.Writer = file
+
+	if r.options.Compress {
+		r.gz = gzip.NewWriter(file)
+		w = r.gz
+	}
+
+	r.buf = bufio.NewWriter(w)
+
+	if r.onOpen != nil && info.Size() == 0 {
+		return r.onOpen(r)
+	}
+
+	return nil
+}
+
+func (r *rotatingFile) tooOld() bool {
+	return r.options.MaxAge > 0 && time.Since(r.openedAt) >= r.options.MaxAge
+}
+
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	// the file went idle past its age
+	if r.file != nil && r.tooOld() {
+		if err := r.Close(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.file == nil {
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.buf.Write(p)
+	r.size += int64(n)
+
+	return n, err
+}
+
+// rotate if the current file is too big or too old
+func (r *rotatingFile) rotate() error {
+	if r.file == nil {
+		return nil
+	}
+
+	tooBig := r.options.MaxSize > 0 && r.size >= r.options.MaxSize
+
+	if !tooBig && !r.tooOld() {
+		return nil
+	}
+
+	return r.Close()
+}
+
+func (r *rotatingFile) Flush() error {
+	if r.file == nil {
+		return nil
+	}
+
+	if err := r.buf.Flush(); err != nil {
+		return err
+	}
+
+	if r.gz != nil {
+		return r.gz.Flush()
+	}
+
+	return nil
+}
+
+func (r *rotatingFile) Close() error {
+	if r.file == nil {
+		return nil
+	}
+
+	err := r.buf.Flush()
+
+	if r.gz != nil {
+		if gzErr := r.gz.Close(); err == nil {
+			err = gzErr
+		}
+	}
+
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	r.file = nil
+	r.gz = nil
+	r.buf = nil
+
+	return err
+}
+
+// writes records as json lines
+type JSONLSink struct {
+	mu   sync.Mutex
+	file *rotatingFile
+}
+
+func NewJSONLSink(options FileSinkOptions) (*JSONLSink, error) {
+	if options.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	return &JSONLSink{
+		file: &rotatingFile{options: options},
+	}, nil
+}
+
+func (s *JSONLSink) Write(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	encoder := json.NewEncoder(s.file)
+
+	for _, record := range records {
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("error encoding record: %s", err)
+		}
+
+		if err := s.file.rotate(); err != nil {
+			return fmt.Errorf("error rotating file: %s", err)
+		}
+	}
+
+	return s.file.Flush()
+}
+
+func (s *JSONLSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.file.Close()
+}
+
+type CSVSinkOptions struct {
+	FileSinkOptions
+
+	// the record keys written as columns, in order
+	Columns []string
+
+	// skip the header line at the top of every file
+	NoHeader bool
+}
+
+// writes records as csv rows
+type CSVSink struct {
+	mu      sync.Mutex
+	columns []string
+	file    *rotatingFile
+	writer  *csv.Writer
+}
+
+func NewCSVSink(options CSVSinkOptions) (*CSVSink, error) {
+	if options.Path == "" {
+		return nil, fmt.Errorf("path is required")
+	}
+
+	if len(options.Columns) == 0 {
+		return nil, fmt.Errorf("at least one column is required")
+	}
+
+	s := &CSVSink{
+		columns: options.Columns,
+		file:    &rotatingFile{options: options.FileSinkOptions},
+	}
+
+	s.writer = csv.NewWriter(s.file)
+
+	if !options.NoHeader {
+		// every new file starts with a header
+		s.file.onOpen = func(w io.Writer) error {
+			header := csv.NewWriter(w)
+			header.Write(s.columns)
+			header.Flush()
+
+			return header.Error()
+		}
+	}
+
+	return s, nil
+}
+
+func (s *CSVSink) Write(records []Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row := make([]string, len(s.columns))
+
+	for _, record := range records {
+		for i, column := range s.columns {
+			row[i] = formatField(record[column])
+		}
+
+		s.writer.Write(row)
+
+		// flush every row, so rotation sees the real size
+		s.writer.Flush()
+
+		if err := s.writer.Error(); err != nil {
+			return fmt.Errorf("error writing row: %s", err)
+		}
+
+		if err := s.file.rotate(); err != nil {
+			return fmt.Errorf("error rotating file: %s", err)
+		}
+	}
+
+	return s.file.Flush()
+}
+
+func (s *CSVSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.writer.Flush()
+
+	return s.file.Close()
+}
+
+func formatField(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano)
+	case *big.Int:
+		if v == nil {
+			return ""
+		}
+		return v.String()
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,126 @@
+package merkle
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// the contents of the files of a directory, by name
+func readFiles(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatalf("failed to read %s: %s", dir, err)
+	}
+
+	names := []string{}
+
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+
+	contents := []string{}
+
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+
+		if err != nil {
+			t.Fatalf("failed to read %s: %s", name, err)
+		}
+
+		contents = append(contents, string(data))
+	}
+
+	return contents
+}
+
+func writeRows(t *testing.T, sink Sink, rows ...string) {
+	t.Helper()
+
+	for _, row := range rows {
+		if err := sink.Write([]Record{{"a": row, "b": "x"}}); err != nil {
+			t.Fatalf("failed to write: %s", err)
+		}
+	}
+}
+
+func TestCSVSinkHeaderOnce(t *testing.T) {
+	dir := t.TempDir()
+	options := CSVSinkOptions{
+		FileSinkOptions: FileSinkOptions{Path: filepath.Join(dir, "out.csv")},
+		Columns:         []string{"a", "b"},
+	}
+
+	// a restart appends to the same file
+	for _, row := range []string{"1", "2"} {
+		sink, err := NewCSVSink(options)
+
+		if err != nil {
+			t.Fatalf("failed to create sink: %s", err)
+		}
+
+		writeRows(t, sink, row)
+		sink.Close()
+	}
+
+	files := readFiles(t, dir)
+
+	if len(files) != 1 || files[0] != "a,b\n1,x\n2,x\n" {
+		t.Fatalf("got files %q", files)
+	}
+}
+
+func TestCSVSinkRotatesBySize(t *testing.T) {
+	dir := t.TempDir()
+
+	sink, err := NewCSVSink(CSVSinkOptions{
+		FileSinkOptions: FileSinkOptions{Path: filepath.Join(dir, "out.csv"), MaxSize: 8},
+		Columns:         []string{"a", "b"},
+	})
+
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+
+	writeRows(t, sink, "1", "2", "3")
+	sink.Close()
+
+	// the header and a row fill a file
+	files := readFiles(t, dir)
+
+	if strings.Join(files, "|") != "a,b\n1,x\n|a,b\n2,x\n|a,b\n3,x\n" {
+		t.Fatalf("got files %q", files)
+	}
+}
+
+func TestJSONLSinkRotatesIdleFile(t *testing.T) {
+	dir := t.TempDir()
+
+	sink, err := NewJSONLSink(FileSinkOptions{Path: filepath.Join(dir, "out.jsonl"), MaxAge: 50 * time.Millisecond})
+
+	if err != nil {
+		t.Fatalf("failed to create sink: %s", err)
+	}
+
+	writeRows(t, sink, "1")
+
+	time.Sleep(100 * time.Millisecond)
+
+	// the first record after the file went idle starts a new file
+	writeRows(t, sink, "2")
+	sink.Close()
+
+	files := readFiles(t, dir)
+
+	if strings.Join(files, "|") != "{\"a\":\"1\",\"b\":\"x\"}\n|{\"a\":\"2\",\"b\":\"x\"}\n" {
+		t.Fatalf("got files %q", files)
+	}
+}
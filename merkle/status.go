@@ -0,0 +1,235 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// where a private transaction is in its lifecycle
+type PoolStatus string
+
+const (
+	// the pool received the transaction
+	PoolStatusReceived PoolStatus = "received"
+
+	// the transaction is auctioned to searchers
+	PoolStatusAuctioned PoolStatus = "auctioned"
+
+	// the transaction was included in a block
+	PoolStatusIncluded PoolStatus = "included"
+
+	// the transaction wasn't included in time
+	PoolStatusExpired PoolStatus = "expired"
+
+	// the transaction can't be included, e.g. it reverts
+	PoolStatusFailed PoolStatus = "failed"
+)
+
+// whether the status won't change anymore
+func (s PoolStatus) Final() bool {
+	return s == PoolStatusIncluded || s == PoolStatusExpired || s == PoolStatusFailed
+}
+
+var (
+	// the private pool doesn't know the transaction (yet)
+	ErrPoolTransactionNotFound = errors.New("transaction not found in the private pool")
+)
+
+type TransactionStatus struct {
+	Hash      common.Hash
+	Status    PoolStatus
+	UpdatedAt time.Time
+
+	// bids received in the auction, once auctioned
+	Bids int
+
+	// where the transaction landed, once included
+	BlockNumber    uint64
+	BundlePosition int
+
+	// the mev refund paid to the fee recipient in wei, zero until included
+	Refund       *big.Int
+	FeeRecipient common.Address
+
+	// why the transaction expired or failed
+	Reason string
+}
+
+type RawTransactionStatus struct {
+	Hash           string `json:"hash"`
+	Status         string `json:"status"`
+	UpdatedAtUnix  int64  `json:"updated_at_unix"`
+	Bids           int    `json:"bids"`
+	BlockNumber    uint64 `json:"block_number"`
+	BundlePosition int    `json:"bundle_position"`
+	Refund         string `json:"refund"`
+	FeeRecipient   string `json:"fee_recipient"`
+	Reason         string `json:"reason"`
+}
+
+func (r *RawTransactionStatus) TransactionStatus() (*TransactionStatus, error) {
+	refund := new(big.Int)
+
+	if r.Refund != "" {
+		if _, ok := refund.SetString(r.Refund, 10); !ok {
+			return nil, fmt.Errorf("failed to parse refund: %s", r.Refund)
+		}
+	}
+
+	status := &TransactionStatus{
+		Hash:           common.HexToHash(r.Hash),
+		Status:         PoolStatus(r.Status),
+		Bids:           r.Bids,
+		BlockNumber:    r.BlockNumber,
+		BundlePosition: r.BundlePosition,
+		Refund:         refund,
+		FeeRecipient:   common.HexToAddress(r.FeeRecipient),
+		Reason:         r.Reason,
+	}
+
+	if r.UpdatedAtUnix > 0 {
+		status.UpdatedAt = time.Unix(r.UpdatedAtUnix, 0)
+	}
+
+	return status, nil
+}
+
+// the status of a transaction sent to the private pool
+func (p *PrivatePool) Status(ctx context.Context, hash common.Hash) (*TransactionStatus, error) {
+	raw := &RawTransactionStatus{}
+
+	err := p.poolRequest(ctx, "GET", fmt.Sprintf("%s/transactions/%s/status", poolApiUrl, hash.String()), nil, raw)
+
+	if StatusCode(err) == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", hash.String(), ErrPoolTransactionNotFound)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := raw.TransactionStatus()
+
+	if err != nil {
+		return nil, err
+	}
+
+	status.Hash = hash
+
+	return status, nil
+}
+
+type WatchStatusOptions struct {
+	// how often statuses are fetched, defaults to 2 seconds
+	Interval time.Duration
+}
+
+func (o *WatchStatusOptions) withDefaults() WatchStatusOptions {
+	opts := WatchStatusOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.Interval <= 0 {
+		opts.Interval = 2 * time.Second
+	}
+
+	return opts
+}
+
+// watch the status of private transactions, a status is pushed every time it
+// changes. A transaction isn't watched anymore once its status is final and the
+// subscription is closed once they all are. Unknown transactions are retried
+func (p *PrivatePool) WatchStatus(ctx context.Context, hashes []common.Hash, options *WatchStatusOptions) *Subscription[*TransactionStatus] {
+	return watch(ctx, hashes, options, watcher[common.Hash, *TransactionStatus]{
+		fetch:    p.Status,
+		notFound: ErrPoolTransactionNotFound,
+		changed:  statusChanged,
+		final: func(status *TransactionStatus) bool {
+			return status.Status.Final()
+		},
+	})
+}
+
+// how to poll the statuses of a kind of item
+type watcher[K comparable, S any] struct {
+	fetch    func(ctx context.Context, key K) (S, error)
+	notFound error
+	changed  func(previous S, status S) bool
+	final    func(status S) bool
+}
+
+// poll statuses until they're all final, pushing them when they change
+func watch[K comparable, S any](ctx context.Context, keys []K, options *WatchStatusOptions, w watcher[K, S]) *Subscription[S] {
+	opts := options.withDefaults()
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := NewSubscription(make(chan S), make(chan error))
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
+
+	go func() {
+		defer sub.Close()
+
+		last := map[K]S{}
+		pending := append([]K{}, keys...)
+
+		for len(pending) > 0 {
+			remaining := []K{}
+
+			for _, key := range pending {
+				status, err := w.fetch(ctx, key)
+
+				if err != nil {
+					if ctx.Err() != nil {
+						return
+					}
+
+					if !errors.Is(err, w.notFound) {
+						sub.PushError(err)
+					}
+
+					remaining = append(remaining, key)
+					continue
+				}
+
+				if previous, ok := last[key]; !ok || w.changed(previous, status) {
+					last[key] = status
+
+					if !sub.Push(status) {
+						return
+					}
+				}
+
+				if !w.final(status) {
+					remaining = append(remaining, key)
+				}
+			}
+
+			pending = remaining
+
+			if len(pending) > 0 && !sleep(ctx, opts.Interval) {
+				return
+			}
+		}
+	}()
+
+	return sub
+}
+
+func statusChanged(previous *TransactionStatus, status *TransactionStatus) bool {
+	return previous.Status != status.Status ||
+		previous.Bids != status.Bids ||
+		previous.BlockNumber != status.BlockNumber ||
+		previous.Refund.Cmp(status.Refund) != 0
+}
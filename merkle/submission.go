@@ -0,0 +1,141 @@
+package merkle
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// where transactions are submitted to the private pool
+const poolSubmissionUrl = poolApiUrl + "/transactions"
+
+// the body of a submission to the private pool
+type poolSubmission struct {
+	// An array of transactions
+	Transactions []string `json:"transactions"`
+
+	// The fee recipient
+	FeeRecipient string `json:"fee_recipient"`
+
+	// Optional, a source tag
+	Source string `json:"source"`
+
+	// Optional, a privacy profile
+	Privacy PrivacyProfile `json:"privacy"`
+
+	// Optional, a list of hints, overrides the privacy profile
+	Hints []Hint `json:"hints"`
+
+	// Optional, a list of allowed bundles for this transaction
+	BundleTypes []string `json:"bundle_types"`
+
+	// Optional, a list of release targets
+	ReleaseTargets []string `json:"release_targets"`
+
+	// Optional, prevent reverts
+	PreventReverts bool `json:"prevent_reverts"`
+}
+
+// post a submission to the pool
+func (p *PrivatePool) submit(ctx context.Context, submission *poolSubmission) (*SubmissionReceipt, error) {
+	raw := &RawSubmissionReceipt{}
+
+	if err := p.poolRequest(ctx, "POST", poolSubmissionUrl, submission, raw); err != nil {
+		apiErr := &APIError{}
+
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest {
+			return nil, newSubmissionRejectedError(apiErr)
+		}
+
+		return nil, err
+	}
+
+	return raw.receipt(), nil
+}
+
+// what the private pool accepted, as the pool returned it. The fields the
+// pool didn't return are left unset rather than taken from the request, so
+// they're never mistaken for what the pool applied
+type SubmissionReceipt struct {
+	// the id of the submission
+	Id string
+
+	// the hashes of the submitted transactions, nil if the pool didn't return them
+	Hashes []common.Hash
+
+	// the privacy settings the pool applied, empty if it didn't return them
+	PrivacyProfile PrivacyProfile
+	Hints          []Hint
+
+	// whether the pool prevents reverts, nil if it didn't say
+	PreventReverts *bool
+
+	// when the pool received the submission, zero if it didn't say
+	ReceivedAt time.Time
+}
+
+type RawSubmissionReceipt struct {
+	Id             string   `json:"id"`
+	Hashes         []string `json:"hashes"`
+	Privacy        string   `json:"privacy"`
+	Hints          []string `json:"hints"`
+	PreventReverts *bool    `json:"prevent_reverts"`
+	ReceivedAtUnix int64    `json:"received_at_unix"`
+}
+
+func (r *RawSubmissionReceipt) receipt() *SubmissionReceipt {
+	receipt := &SubmissionReceipt{
+		Id:             r.Id,
+		PrivacyProfile: PrivacyProfile(r.Privacy),
+		PreventReverts: r.PreventReverts,
+	}
+
+	if r.Hashes != nil {
+		receipt.Hashes = make([]common.Hash, len(r.Hashes))
+
+		for i, hash := range r.Hashes {
+			receipt.Hashes[i] = common.HexToHash(hash)
+		}
+	}
+
+	if r.Hints != nil {
+		receipt.Hints = make([]Hint, len(r.Hints))
+
+		for i, hint := range r.Hints {
+			receipt.Hints[i] = Hint(hint)
+		}
+	}
+
+	if r.ReceivedAtUnix > 0 {
+		receipt.ReceivedAt = time.Unix(r.ReceivedAtUnix, 0)
+	}
+
+	return receipt
+}
+
+// the private pool rejected a submission, e.g. an invalid transaction or
+// an unknown hint. It wraps the APIError of the response
+type SubmissionRejectedError struct {
+	APIError
+
+	// why the pool rejected it, the body if it isn't json
+	Reason string
+}
+
+func newSubmissionRejectedError(apiErr *APIError) *SubmissionRejectedError {
+	return &SubmissionRejectedError{
+		APIError: *apiErr,
+		Reason:   errorReason(apiErr.Body),
+	}
+}
+
+func (e *SubmissionRejectedError) Error() string {
+	return "submission rejected: " + e.Reason
+}
+
+func (e *SubmissionRejectedError) Unwrap() error {
+	return &e.APIError
+}
@@ -0,0 +1,159 @@
+package merkle
+
+import (
+	"sync"
+)
+
+// a stream of items together with the errors produced while streaming them
+type Subscription[T any] struct {
+	items  chan T
+	errors chan error
+	done   chan struct{}
+
+	closeOnce sync.Once
+	closeErr  error
+
+	mu      sync.Mutex
+	onClose []func() error
+}
+
+// wrap an existing pair of item and error channels in a subscription
+func NewSubscription[T any](items chan T, errors chan error) *Subscription[T] {
+	return &Subscription[T]{
+		items:  items,
+		errors: errors,
+		done:   make(chan struct{}),
+	}
+}
+
+// the items of the subscription
+func (s *Subscription[T]) Items() <-chan T {
+	return s.items
+}
+
+// the errors of the subscription
+func (s *Subscription[T]) Err() <-chan error {
+	return s.errors
+}
+
+// closed once the subscription is closed
+func (s *Subscription[T]) Done() <-chan struct{} {
+	return s.done
+}
+
+// close the subscription, it waits for anything attached to
+// it to shut down and returns the first error they reported
+func (s *Subscription[T]) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+
+		s.mu.Lock()
+		hooks := s.onClose
+		s.mu.Unlock()
+
+		for _, hook := range hooks {
+			if err := hook(); err != nil && s.closeErr == nil {
+				s.closeErr = err
+			}
+		}
+	})
+
+	return s.closeErr
+}
+
+// close the subscription and its channels, so ranging over them ends. Only for
+// subscriptions whose items and errors are all pushed by the calling goroutine
+func (s *Subscription[T]) end() {
+	s.Close()
+
+	close(s.items)
+	close(s.errors)
+}
+
+// register a function to run when the subscription is closed
+func (s *Subscription[T]) OnClose(hook func() error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onClose = append(s.onClose, hook)
+}
+
+// push an error on the subscription, gives up if the subscription is closed
+func (s *Subscription[T]) PushError(err error) {
+	select {
+	case s.errors <- err:
+	case <-s.done:
+	}
+}
+
+// send an error without blocking, the oldest error is dropped if the consumer
+// isn't reading them. Only for subscriptions with a buffered error channel
+func (s *Subscription[T]) report(err error) {
+	for {
+		select {
+		case s.errors <- err:
+			return
+		default:
+		}
+
+		select {
+		case <-s.errors:
+		default:
+		}
+	}
+}
+
+// push an item on the subscription, returns false if the subscription is closed
+func (s *Subscription[T]) Push(item T) bool {
+	select {
+	case s.items <- item:
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+// a subscription of the items of another one, converted. Items the
+// conversion rejects are skipped, closing it closes the original one
+func pipe[A any, B any](in *Subscription[A], convert func(A) (B, bool)) *Subscription[B] {
+	out := NewSubscription(make(chan B), make(chan error))
+	out.OnClose(in.Close)
+
+	go func() {
+		errs := in.Err()
+
+		for {
+			select {
+			case item, ok := <-in.Items():
+				if !ok {
+					out.Close()
+					return
+				}
+
+				converted, ok := convert(item)
+
+				if !ok {
+					continue
+				}
+
+				if !out.Push(converted) {
+					return
+				}
+			case err, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+
+				out.PushError(err)
+			case <-in.Done():
+				out.Close()
+				return
+			case <-out.Done():
+				return
+			}
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,121 @@
+package merkle
+
+import (
+	"errors"
+	"fmt"
+)
+
+// a kind of bundle searchers may build around a transaction
+type BundleType string
+
+const (
+	// bundles placing transactions after the user's
+	BundleTypeBackrun BundleType = "backrun"
+
+	// bundles liquidating positions the user's transaction made liquidatable
+	BundleTypeLiquidation BundleType = "liquidation"
+)
+
+// where a transaction may be released
+type ReleaseTarget string
+
+const (
+	// every builder merkle works with
+	ReleaseTargetAllBuilders ReleaseTarget = "builders"
+
+	ReleaseTargetFlashbots   ReleaseTarget = "flashbots"
+	ReleaseTargetBeaverbuild ReleaseTarget = "beaverbuild"
+	ReleaseTargetTitan       ReleaseTarget = "titan"
+	ReleaseTargetRsync       ReleaseTarget = "rsync"
+	ReleaseTargetBuilder0x69 ReleaseTarget = "builder0x69"
+
+	// the public mempool, e.g. if the transaction isn't included by builders
+	ReleaseTargetPublicMempool ReleaseTarget = "mempool"
+)
+
+// the bundle types and release targets the private pool supports,
+// submissions with anything else are rejected before they're sent
+var (
+	SupportedBundleTypes = []BundleType{
+		BundleTypeBackrun,
+		BundleTypeLiquidation,
+	}
+
+	SupportedReleaseTargets = []ReleaseTarget{
+		ReleaseTargetAllBuilders,
+		ReleaseTargetFlashbots,
+		ReleaseTargetBeaverbuild,
+		ReleaseTargetTitan,
+		ReleaseTargetRsync,
+		ReleaseTargetBuilder0x69,
+		ReleaseTargetPublicMempool,
+	}
+)
+
+var (
+	ErrUnsupportedBundleType    = errors.New("unsupported bundle type")
+	ErrUnsupportedReleaseTarget = errors.New("unsupported release target")
+)
+
+func supported[T comparable](value T, values []T) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate bundle types and convert them for a submission, duplicates are removed
+func bundleTypeStrings(bundleTypes []BundleType) ([]string, error) {
+	values := []string{}
+	seen := map[BundleType]bool{}
+
+	for _, bundleType := range bundleTypes {
+		if !supported(bundleType, SupportedBundleTypes) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedBundleType, bundleType)
+		}
+
+		if !seen[bundleType] {
+			seen[bundleType] = true
+			values = append(values, string(bundleType))
+		}
+	}
+
+	return values, nil
+}
+
+// validate release targets and convert them for a submission, duplicates are removed
+func releaseTargetStrings(targets []ReleaseTarget) ([]string, error) {
+	values := []string{}
+	seen := map[ReleaseTarget]bool{}
+
+	for _, target := range targets {
+		if !supported(target, SupportedReleaseTargets) {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedReleaseTarget, target)
+		}
+
+		if !seen[target] {
+			seen[target] = true
+			values = append(values, string(target))
+		}
+	}
+
+	return values, nil
+}
+
+// set the bundle types and release targets of a submission
+func (s *poolSubmission) setTargets(bundleTypes []BundleType, releaseTargets []ReleaseTarget) error {
+	var err error
+
+	if s.BundleTypes, err = bundleTypeStrings(bundleTypes); err != nil {
+		return err
+	}
+
+	if s.ReleaseTargets, err = releaseTargetStrings(releaseTargets); err != nil {
+		return err
+	}
+
+	return nil
+}
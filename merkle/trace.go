@@ -0,0 +1,216 @@
+package merkle
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the trace of one hash of TraceMany
+type TraceResult struct {
+	Hash  string
+	Trace *MerkleTrace
+
+	// why the hash couldn't be traced, ErrTraceNotFound if merkle hasn't seen it
+	Err error
+}
+
+// returned by TraceMany when some hashes couldn't be traced
+type TraceManyError struct {
+	Failed []TraceResult
+	Total  int
+}
+
+func (e *TraceManyError) Error() string {
+	reasons := []string{}
+
+	for i, result := range e.Failed {
+		// keep the message short for big batches
+		if i == 3 {
+			reasons = append(reasons, "...")
+			break
+		}
+
+		reasons = append(reasons, result.Err.Error())
+	}
+
+	return fmt.Sprintf("failed to trace %d of %d hashes: %s", len(e.Failed), e.Total, strings.Join(reasons, "; "))
+}
+
+// trace many transactions, at most concurrency at a time. The results are in
+// the order of the hashes. If some hashes fail, the other results are still
+// returned along with a *TraceManyError
+func (t *TransactionStream) TraceMany(ctx context.Context, hashes []string, concurrency int) ([]TraceResult, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	results := make([]TraceResult, len(hashes))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < concurrency && w < len(hashes); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range jobs {
+				trace, err := t.Trace(ctx, hashes[i])
+
+				results[i] = TraceResult{
+					Hash:  hashes[i],
+					Trace: trace,
+					Err:   err,
+				}
+			}
+		}()
+	}
+
+	for i := range hashes {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			// the hashes left are never traced
+			results[i] = TraceResult{Hash: hashes[i], Err: ctx.Err()}
+		}
+	}
+
+	close(jobs)
+	wg.Wait()
+
+	failed := []TraceResult{}
+
+	for _, result := range results {
+		if result.Err != nil {
+			failed = append(failed, result)
+		}
+	}
+
+	if len(failed) > 0 {
+		return results, &TraceManyError{Failed: failed, Total: len(hashes)}
+	}
+
+	return results, nil
+}
+
+type WaitForTraceOptions struct {
+	// when the transaction was submitted, defaults to when WaitForTrace is called
+	SubmittedAt time.Time
+
+	// first delay between polls, defaults to 250ms
+	InitialInterval time.Duration
+
+	// longest delay between polls, defaults to 5 seconds
+	MaxInterval time.Duration
+
+	// growth of the delay between polls, defaults to 2
+	Multiplier float64
+}
+
+func (o *WaitForTraceOptions) withDefaults() WaitForTraceOptions {
+	opts := WaitForTraceOptions{}
+
+	if o != nil {
+		opts = *o
+	}
+
+	if opts.SubmittedAt.IsZero() {
+		opts.SubmittedAt = time.Now()
+	}
+
+	if opts.InitialInterval <= 0 {
+		opts.InitialInterval = 250 * time.Millisecond
+	}
+
+	if opts.MaxInterval <= 0 {
+		opts.MaxInterval = 5 * time.Second
+	}
+
+	if opts.Multiplier < 1 {
+		opts.Multiplier = 2
+	}
+
+	return opts
+}
+
+// how a transaction propagated
+type TracePropagation struct {
+	Trace *MerkleTrace
+
+	// from the submission to FirstSeenAt, negative if the transaction
+	// was seen before it was submitted
+	TimeToFirstSeen time.Duration
+
+	// number of distinct origins that observed the transaction
+	Origins int
+
+	// between the first and the last observation
+	Spread time.Duration
+}
+
+// derive the propagation metrics of a trace
+func NewTracePropagation(trace *MerkleTrace, submittedAt time.Time) *TracePropagation {
+	propagation := &TracePropagation{
+		Trace:           trace,
+		TimeToFirstSeen: trace.FirstSeenAt.Sub(submittedAt),
+	}
+
+	origins := map[string]bool{}
+
+	var first, last time.Time
+
+	for i, observation := range trace.Trace {
+		origins[observation.Origin] = true
+
+		if i == 0 || observation.Time.Before(first) {
+			first = observation.Time
+		}
+
+		if i == 0 || observation.Time.After(last) {
+			last = observation.Time
+		}
+	}
+
+	propagation.Origins = len(origins)
+	propagation.Spread = last.Sub(first)
+
+	return propagation
+}
+
+// poll the trace of a transaction with backoff until merkle has seen it,
+// or the context is done
+func (t *TransactionStream) WaitForTrace(ctx context.Context, hash string, options *WaitForTraceOptions) (*TracePropagation, error) {
+	opts := options.withDefaults()
+
+	interval := opts.InitialInterval
+
+	for {
+		trace, err := t.Trace(ctx, hash)
+
+		if err == nil {
+			return NewTracePropagation(trace, opts.SubmittedAt), nil
+		}
+
+		// the request was rejected, polling won't help
+		code := StatusCode(err)
+
+		if code >= 400 && code < 500 && code != http.StatusNotFound && code != http.StatusTooManyRequests {
+			return nil, err
+		}
+
+		if !sleep(ctx, interval) {
+			return nil, fmt.Errorf("error waiting for trace of %s: %w", hash, ctx.Err())
+		}
+
+		interval = time.Duration(float64(interval) * opts.Multiplier)
+
+		if interval > opts.MaxInterval {
+			interval = opts.MaxInterval
+		}
+	}
+}
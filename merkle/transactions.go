@@ -1,19 +1,24 @@
 package merkle
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"golang.org/x/net/websocket"
 )
 
 type TransactionStream struct {
 	sdk *MerkleSDK
+
+	decodersMu sync.RWMutex
+	decoders   map[byte]TransactionDecoder
+
+	stats streamCounters
 }
 
 func NewTransactionStream(sdk *MerkleSDK) *TransactionStream {
@@ -23,18 +28,74 @@ func NewTransactionStream(sdk *MerkleSDK) *TransactionStream {
 }
 
 func (t *TransactionStream) Stream(chainId MerkleChainId) (chan *types.Transaction, chan error) {
-	errStream := make(chan error)
-	txStream := make(chan *types.Transaction)
+	sub, err := t.Subscribe(context.Background(), chainId)
 
-	incomingMessages := make(chan []uint8)
+	if err != nil {
+		errStream := make(chan error)
 
-	if t.sdk.ApiKey == "" {
 		go func() {
-			errStream <- fmt.Errorf("API key is not set")
+			errStream <- err
 		}()
-		return txStream, errStream
+
+		return make(chan *types.Transaction), errStream
+	}
+
+	return sub.items, sub.errors
+}
+
+// stream the transactions of a chain through the sdk transport,
+// until the context is done or the subscription is closed. Transactions
+// that can't be decoded into a types.Transaction are dropped, use
+// SubscribeAll to receive them
+func (t *TransactionStream) Subscribe(ctx context.Context, chainId MerkleChainId) (*Subscription[*types.Transaction], error) {
+	raw, err := t.subscribeRaw(ctx, chainId)
+
+	if err != nil {
+		return nil, err
 	}
 
+	return pipe(raw, func(message []byte) (*types.Transaction, bool) {
+		item := t.decode(message)
+
+		if item.Transaction == nil {
+			t.stats.dropped.Add(1)
+			return nil, false
+		}
+
+		return item.Transaction, true
+	}), nil
+}
+
+// stream every transaction of a chain, including the ones the sdk can't decode
+func (t *TransactionStream) SubscribeAll(ctx context.Context, chainId MerkleChainId) (*Subscription[*StreamItem], error) {
+	raw, err := t.subscribeRaw(ctx, chainId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return pipe(raw, func(message []byte) (*StreamItem, bool) {
+		return t.decode(message), true
+	}), nil
+}
+
+func (t *TransactionStream) subscribeRaw(ctx context.Context, chainId MerkleChainId) (*Subscription[[]byte], error) {
+	if t.sdk.ApiKey == "" {
+		return nil, fmt.Errorf("API key is not set")
+	}
+
+	return t.sdk.GetTransport().Transactions(ctx, t.sdk.ApiKey, chainId)
+}
+
+func (w *WebsocketTransport) Transactions(ctx context.Context, apiKey string, chainId MerkleChainId) (*Subscription[[]byte], error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	sub := NewSubscription(make(chan []byte), make(chan error))
+	sub.OnClose(func() error {
+		cancel()
+		return nil
+	})
+
 	go func() {
 		retries := 0
 
@@ -42,24 +103,35 @@ func (t *TransactionStream) Stream(chainId MerkleChainId) (chan *types.Transacti
 			retries++
 
 			var address = "txs.merkle.io"
-			ws, err := websocket.Dial(fmt.Sprintf("wss://%s/ws/%s/%d", address, t.sdk.ApiKey, int64(chainId)), "", fmt.Sprintf("http://%s/", address))
+			ws, err := websocket.Dial(fmt.Sprintf("wss://%s/ws/%s/%d", address, apiKey, int64(chainId)), "", fmt.Sprintf("http://%s/", address))
 
 			if err != nil {
 				// if it's less than 5 retries, try again
-				if retries < 5 {
-					time.Sleep(1 * time.Second)
+				if retries < 5 && sleep(ctx, 1*time.Second) {
 					continue
 				}
 
-				go func() {
-					errStream <- err
-				}()
+				if ctx.Err() == nil {
+					// the url carries the api key
+					sub.PushError(fmt.Errorf("failed to connect to transactions: %s", redactApiKey(err.Error(), apiKey)))
+				}
 				return
 			}
 
 			// reset the retries
 			retries = 0
 
+			// close the connection when the subscription is closed
+			connDone := make(chan struct{})
+
+			go func() {
+				select {
+				case <-ctx.Done():
+					ws.Close()
+				case <-connDone:
+				}
+			}()
+
 			for {
 				var message []uint8
 
@@ -71,32 +143,35 @@ func (t *TransactionStream) Stream(chainId MerkleChainId) (chan *types.Transacti
 					// if we couldn't read the message, try to reconnect
 					break
 				}
-				incomingMessages <- message
-			}
-
-			time.Sleep(1 * time.Second)
-		}
-	}()
-
-	go func() {
-		for {
-			select {
-			case message := <-incomingMessages:
-				tx := types.Transaction{}
 
-				err := tx.UnmarshalBinary(message)
-
-				if err != nil {
-					// if we couldn't parse the transaction, skip it
-					continue
+				if !sub.Push(message) {
+					break
 				}
+			}
+
+			close(connDone)
+			ws.Close()
 
-				txStream <- &tx
+			if !sleep(ctx, 1*time.Second) {
+				return
 			}
 		}
 	}()
 
-	return txStream, errStream
+	return sub, nil
+}
+
+// sleep for a duration, returns false if the context is done first
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
 }
 
 type MerkleTrace struct {
@@ -112,77 +187,60 @@ type Observation struct {
 	Origin string
 }
 
-// trace a transaction
-func (t *TransactionStream) Trace(hash string) (*MerkleTrace, error) {
-	// url is https://txs.merkle.io/trace/<hash>
-	res, err := http.Get(fmt.Sprintf("https://txs.merkle.io/trace/%s", hash))
-
-	if err != nil {
-		return nil, fmt.Errorf("error fetching trace: %s", err)
-	}
+// trace a transaction, returns ErrTraceNotFound if merkle hasn't seen it
+func (t *TransactionStream) Trace(ctx context.Context, hash string) (*MerkleTrace, error) {
+	var trace MerkleTrace
 
-	// check if we got a 404
-	if res.StatusCode == 404 {
-		return nil, nil
-	}
+	// url is https://txs.merkle.io/trace/<hash>
+	err := MakeGet(ctx, fmt.Sprintf("https://txs.merkle.io/trace/%s", hash), t.sdk.GetApiKey(), &trace)
 
-	// check if we got a 200
-	if res.StatusCode != 200 {
-		return nil, fmt.Errorf("error fetching trace: %s", res.Status)
+	if StatusCode(err) == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrTraceNotFound, hash)
 	}
 
-	body, err := ioutil.ReadAll(res.Body)
-
 	if err != nil {
-		return nil, fmt.Errorf("error reading trace: %s", err)
-	}
-
-	// decode the response
-	var trace MerkleTrace
-
-	err = json.Unmarshal(body, &trace)
-
-	if err != nil {
-		return nil, fmt.Errorf("error decoding trace: %s", err)
+		return nil, fmt.Errorf("error fetching trace: %w", err)
 	}
 
 	return &trace, nil
 }
 
-// inject a tx
-func (t *TransactionStream) Inject(chainId MerkleChainId, tx *types.Transaction) error {
+// inject a tx into the public mempool, returns its hash. Node errors are
+// returned as a *RPCError matching ErrNonceTooLow, ErrUnderpriced, etc.
+func (t *TransactionStream) Inject(ctx context.Context, chainId MerkleChainId, tx *types.Transaction) (common.Hash, error) {
 	bts, err := tx.MarshalBinary()
 
 	if err != nil {
-		return fmt.Errorf("error marshalling tx: %s", err)
+		return common.Hash{}, fmt.Errorf("error marshalling tx: %s", err)
 	}
 
 	// body of eth_sendRawTransaction
-	body := map[string]interface{}{
-		"jsonrpc": "2.0",
-		"method":  "eth_sendRawTransaction",
-		"params":  []string{fmt.Sprintf("0x%x", bts)},
-		"id":      1,
+	body := &rpcRequest{
+		Jsonrpc: "2.0",
+		Method:  "eth_sendRawTransaction",
+		Params:  []string{fmt.Sprintf("0x%x", bts)},
+		Id:      1,
 	}
 
-	bodyBytes, err := json.Marshal(body)
+	var res rpcResponse
+
+	// docs: https://docs.merkle.io/transaction-network/injection
+	err = postRpc(ctx, t.rpcUrl(chainId), t.sdk.ApiKey, body, &res)
 
 	if err != nil {
-		return fmt.Errorf("error marshalling body: %s", err)
+		return common.Hash{}, fmt.Errorf("error injecting tx: %w", err)
 	}
 
-	// url is https://txs.merkle.io/inject/<chainId>
-	// docs: https://docs.merkle.io/transaction-network/injection
-	res, err := http.Post(fmt.Sprintf("https://txs.merkle.io/rpc/%s/%d", t.sdk.ApiKey, int64(chainId)), "application/json", bytes.NewReader(bodyBytes))
+	hash, err := res.txHash()
 
 	if err != nil {
-		return fmt.Errorf("error injecting tx: %s", err)
+		return common.Hash{}, fmt.Errorf("error injecting tx: %w", err)
 	}
 
-	// check if we got a 200
-	if res.StatusCode != 200 {
-		return fmt.Errorf("error injecting tx: %s", res.Status)
-	}
+	return hash, nil
+}
 
-	return nil
+// url is https://txs.merkle.io/rpc/<key>/<chainId>
+func (t *TransactionStream) rpcUrl(chainId MerkleChainId) string {
+	return fmt.Sprintf("https://txs.merkle.io/rpc/%s/%d", t.sdk.ApiKey, int64(chainId))
 }
@@ -0,0 +1,46 @@
+package merkle
+
+import (
+	"context"
+	"time"
+)
+
+// how the sdk connects to the merkle streams, the default is a websocket
+// transport, see the grpctransport package for a gRPC one
+type Transport interface {
+	// stream the transactions of a chain, in their binary encoding
+	Transactions(ctx context.Context, apiKey string, chainId MerkleChainId) (*Subscription[[]byte], error)
+
+	// stream the auctions of the private pool
+	Auctions(ctx context.Context, apiKey string) (*Subscription[*Auction], error)
+}
+
+// the websocket transport to txs.merkle.io and mempool.merkle.io
+type WebsocketTransport struct {
+	// the maximum size of an auction message, defaults to DefaultMaxMessageSize
+	MaxMessageSize int
+
+	// how long the auctions connection can go without a message before it's
+	// considered lost and reopened, defaults to 60 seconds
+	IdleTimeout time.Duration
+}
+
+func NewWebsocketTransport() *WebsocketTransport {
+	return &WebsocketTransport{}
+}
+
+func (w *WebsocketTransport) maxMessageSize() int {
+	if w.MaxMessageSize <= 0 {
+		return DefaultMaxMessageSize
+	}
+
+	return w.MaxMessageSize
+}
+
+func (w *WebsocketTransport) idleTimeout() time.Duration {
+	if w.IdleTimeout <= 0 {
+		return 60 * time.Second
+	}
+
+	return w.IdleTimeout
+}
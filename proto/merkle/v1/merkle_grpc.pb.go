@@ -0,0 +1,283 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: merkle/v1/merkle.proto
+
+package merklev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	MerkleService_StreamTransactions_FullMethodName = "/merkle.v1.MerkleService/StreamTransactions"
+	MerkleService_StreamAuctions_FullMethodName     = "/merkle.v1.MerkleService/StreamAuctions"
+	MerkleService_SendBid_FullMethodName            = "/merkle.v1.MerkleService/SendBid"
+	MerkleService_Simulate_FullMethodName           = "/merkle.v1.MerkleService/Simulate"
+)
+
+// MerkleServiceClient is the client API for MerkleService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MerkleServiceClient interface {
+	// Stream the transactions of a chain, as they are seen.
+	StreamTransactions(ctx context.Context, in *StreamTransactionsRequest, opts ...grpc.CallOption) (MerkleService_StreamTransactionsClient, error)
+	// Stream the auctions of the private pool.
+	StreamAuctions(ctx context.Context, in *StreamAuctionsRequest, opts ...grpc.CallOption) (MerkleService_StreamAuctionsClient, error)
+	// Send a bid (a backrun) for an auction.
+	SendBid(ctx context.Context, in *Bid, opts ...grpc.CallOption) (*SendBidResponse, error)
+	// Simulate a bundle of calls.
+	Simulate(ctx context.Context, in *SimulationRequest, opts ...grpc.CallOption) (*SimulationResponse, error)
+}
+
+type merkleServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMerkleServiceClient(cc grpc.ClientConnInterface) MerkleServiceClient {
+	return &merkleServiceClient{cc}
+}
+
+func (c *merkleServiceClient) StreamTransactions(ctx context.Context, in *StreamTransactionsRequest, opts ...grpc.CallOption) (MerkleService_StreamTransactionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MerkleService_ServiceDesc.Streams[0], MerkleService_StreamTransactions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &merkleServiceStreamTransactionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MerkleService_StreamTransactionsClient interface {
+	Recv() (*Transaction, error)
+	grpc.ClientStream
+}
+
+type merkleServiceStreamTransactionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *merkleServiceStreamTransactionsClient) Recv() (*Transaction, error) {
+	m := new(Transaction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *merkleServiceClient) StreamAuctions(ctx context.Context, in *StreamAuctionsRequest, opts ...grpc.CallOption) (MerkleService_StreamAuctionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &MerkleService_ServiceDesc.Streams[1], MerkleService_StreamAuctions_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &merkleServiceStreamAuctionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type MerkleService_StreamAuctionsClient interface {
+	Recv() (*Auction, error)
+	grpc.ClientStream
+}
+
+type merkleServiceStreamAuctionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *merkleServiceStreamAuctionsClient) Recv() (*Auction, error) {
+	m := new(Auction)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *merkleServiceClient) SendBid(ctx context.Context, in *Bid, opts ...grpc.CallOption) (*SendBidResponse, error) {
+	out := new(SendBidResponse)
+	err := c.cc.Invoke(ctx, MerkleService_SendBid_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merkleServiceClient) Simulate(ctx context.Context, in *SimulationRequest, opts ...grpc.CallOption) (*SimulationResponse, error) {
+	out := new(SimulationResponse)
+	err := c.cc.Invoke(ctx, MerkleService_Simulate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MerkleServiceServer is the server API for MerkleService service.
+// All implementations must embed UnimplementedMerkleServiceServer
+// for forward compatibility
+type MerkleServiceServer interface {
+	// Stream the transactions of a chain, as they are seen.
+	StreamTransactions(*StreamTransactionsRequest, MerkleService_StreamTransactionsServer) error
+	// Stream the auctions of the private pool.
+	StreamAuctions(*StreamAuctionsRequest, MerkleService_StreamAuctionsServer) error
+	// Send a bid (a backrun) for an auction.
+	SendBid(context.Context, *Bid) (*SendBidResponse, error)
+	// Simulate a bundle of calls.
+	Simulate(context.Context, *SimulationRequest) (*SimulationResponse, error)
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+// UnimplementedMerkleServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedMerkleServiceServer struct {
+}
+
+func (UnimplementedMerkleServiceServer) StreamTransactions(*StreamTransactionsRequest, MerkleService_StreamTransactionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamTransactions not implemented")
+}
+func (UnimplementedMerkleServiceServer) StreamAuctions(*StreamAuctionsRequest, MerkleService_StreamAuctionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAuctions not implemented")
+}
+func (UnimplementedMerkleServiceServer) SendBid(context.Context, *Bid) (*SendBidResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendBid not implemented")
+}
+func (UnimplementedMerkleServiceServer) Simulate(context.Context, *SimulationRequest) (*SimulationResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Simulate not implemented")
+}
+func (UnimplementedMerkleServiceServer) mustEmbedUnimplementedMerkleServiceServer() {}
+
+// UnsafeMerkleServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MerkleServiceServer will
+// result in compilation errors.
+type UnsafeMerkleServiceServer interface {
+	mustEmbedUnimplementedMerkleServiceServer()
+}
+
+func RegisterMerkleServiceServer(s grpc.ServiceRegistrar, srv MerkleServiceServer) {
+	s.RegisterService(&MerkleService_ServiceDesc, srv)
+}
+
+func _MerkleService_StreamTransactions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamTransactionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MerkleServiceServer).StreamTransactions(m, &merkleServiceStreamTransactionsServer{stream})
+}
+
+type MerkleService_StreamTransactionsServer interface {
+	Send(*Transaction) error
+	grpc.ServerStream
+}
+
+type merkleServiceStreamTransactionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *merkleServiceStreamTransactionsServer) Send(m *Transaction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MerkleService_StreamAuctions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAuctionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MerkleServiceServer).StreamAuctions(m, &merkleServiceStreamAuctionsServer{stream})
+}
+
+type MerkleService_StreamAuctionsServer interface {
+	Send(*Auction) error
+	grpc.ServerStream
+}
+
+type merkleServiceStreamAuctionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *merkleServiceStreamAuctionsServer) Send(m *Auction) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _MerkleService_SendBid_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Bid)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).SendBid(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_SendBid_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).SendBid(ctx, req.(*Bid))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerkleService_Simulate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SimulationRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerkleServiceServer).Simulate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerkleService_Simulate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerkleServiceServer).Simulate(ctx, req.(*SimulationRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MerkleService_ServiceDesc is the grpc.ServiceDesc for MerkleService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MerkleService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "merkle.v1.MerkleService",
+	HandlerType: (*MerkleServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SendBid",
+			Handler:    _MerkleService_SendBid_Handler,
+		},
+		{
+			MethodName: "Simulate",
+			Handler:    _MerkleService_Simulate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamTransactions",
+			Handler:       _MerkleService_StreamTransactions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamAuctions",
+			Handler:       _MerkleService_StreamAuctions_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "merkle/v1/merkle.proto",
+}
@@ -0,0 +1,476 @@
+// Package rpcproxy is an Ethereum JSON-RPC proxy that sends transactions through
+// merkle. Wallets and bots use it by changing their RPC url: transactions go to the
+// private pool or are injected, every other method is forwarded to an upstream node.
+package rpcproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+// where a transaction is sent
+type Route string
+
+const (
+	// send to the merkle private pool
+	RoutePool Route = "pool"
+
+	// inject in the public mempool through merkle
+	RouteInject Route = "inject"
+)
+
+// route the transactions of some senders or to some destinations,
+// a transaction matches if it matches either list
+type Rule struct {
+	Senders      []common.Address
+	Destinations []common.Address
+
+	Route Route
+}
+
+func (r Rule) matches(from common.Address, to *common.Address) bool {
+	for _, sender := range r.Senders {
+		if sender == from {
+			return true
+		}
+	}
+
+	if to == nil {
+		return false
+	}
+
+	for _, destination := range r.Destinations {
+		if destination == *to {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Config struct {
+	// the node every other method is forwarded to, required
+	Upstream string
+
+	// the route of transactions no rule matches, defaults to RoutePool
+	DefaultRoute Route
+
+	// the first matching rule routes a transaction
+	Rules []Rule
+
+	// private pool settings, see merkle.NewTransactionOptions
+	FeeRecipient   common.Address
+	Hints          []merkle.Hint
+	PrivacyProfile merkle.PrivacyProfile
+	Source         string
+	PreventRevert  bool
+	BundleTypes    []merkle.BundleType
+	ReleaseTargets []merkle.ReleaseTarget
+
+	// the chain of injected transactions that don't have a chain id,
+	// defaults to the chain id of the transaction
+	ChainId merkle.MerkleChainId
+
+	// maximum size of a request body, defaults to 5MB
+	MaxBodySize int64
+
+	// client used to reach the upstream node, defaults to http.DefaultClient
+	Client *http.Client
+
+	// called with the errors hidden from clients, they can carry secrets
+	// like the api key. Defaults to logging them with the log package
+	OnError func(err error)
+}
+
+// a json-rpc proxy, it's an http.Handler
+type Proxy struct {
+	sdk    *merkle.MerkleSDK
+	config Config
+}
+
+func New(sdk *merkle.MerkleSDK, config Config) (*Proxy, error) {
+	if config.Upstream == "" {
+		return nil, fmt.Errorf("an upstream rpc url is required")
+	}
+
+	if config.DefaultRoute == "" {
+		config.DefaultRoute = RoutePool
+	}
+
+	for _, route := range append([]Route{config.DefaultRoute}, rulesRoutes(config.Rules)...) {
+		if route != RoutePool && route != RouteInject {
+			return nil, fmt.Errorf("unknown route: %s", route)
+		}
+	}
+
+	privacy := merkle.PrivacySettings{Profile: config.PrivacyProfile, Hints: config.Hints}
+
+	if err := privacy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid privacy settings: %w", err)
+	}
+
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 5 << 20
+	}
+
+	if config.Client == nil {
+		config.Client = http.DefaultClient
+	}
+
+	if config.OnError == nil {
+		config.OnError = func(err error) {
+			log.Printf("rpcproxy: %s", err)
+		}
+	}
+
+	return &Proxy{
+		sdk:    sdk,
+		config: config,
+	}, nil
+}
+
+func rulesRoutes(rules []Rule) []Route {
+	routes := []Route{}
+
+	for _, rule := range rules {
+		routes = append(routes, rule.Route)
+	}
+
+	return routes
+}
+
+type request struct {
+	Jsonrpc string            `json:"jsonrpc"`
+	Method  string            `json:"method"`
+	Params  []json.RawMessage `json:"params"`
+	Id      json.RawMessage   `json:"id"`
+}
+
+type response struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+const (
+	codeParseError    = -32700
+	codeInvalidParams = -32602
+	codeServerError   = -32000
+)
+
+func errorResponse(id json.RawMessage, code int, message string) *response {
+	return &response{Jsonrpc: "2.0", Id: id, Error: &rpcError{Code: code, Message: message}}
+}
+
+// the methods handled by the proxy
+func intercepted(method string) bool {
+	return method == "eth_sendRawTransaction" || method == "eth_sendPrivateTransaction"
+}
+
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, p.config.MaxBodySize+1))
+
+	if err != nil {
+		http.Error(w, "error reading body", http.StatusBadRequest)
+		return
+	}
+
+	if int64(len(body)) > p.config.MaxBodySize {
+		http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	trimmed := bytes.TrimSpace(body)
+
+	if len(trimmed) > 0 && trimmed[0] == '[' {
+		p.serveBatch(w, r, body)
+		return
+	}
+
+	req := &request{}
+
+	if err := json.Unmarshal(body, req); err != nil {
+		writeJSON(w, errorResponse(nil, codeParseError, "parse error"))
+		return
+	}
+
+	if !intercepted(req.Method) {
+		p.forward(w, r, body)
+		return
+	}
+
+	writeJSON(w, p.handle(r.Context(), req))
+}
+
+// intercepted requests of a batch are handled here, the
+// others are forwarded in a single batch to the upstream node
+func (p *Proxy) serveBatch(w http.ResponseWriter, r *http.Request, body []byte) {
+	batch := []json.RawMessage{}
+
+	if err := json.Unmarshal(body, &batch); err != nil {
+		writeJSON(w, errorResponse(nil, codeParseError, "parse error"))
+		return
+	}
+
+	responses := []interface{}{}
+	forwarded := []json.RawMessage{}
+
+	for _, raw := range batch {
+		req := &request{}
+
+		if err := json.Unmarshal(raw, req); err != nil {
+			responses = append(responses, errorResponse(nil, codeParseError, "parse error"))
+			continue
+		}
+
+		if !intercepted(req.Method) {
+			forwarded = append(forwarded, raw)
+			continue
+		}
+
+		responses = append(responses, p.handle(r.Context(), req))
+	}
+
+	if len(forwarded) > 0 {
+		upstream := []json.RawMessage{}
+
+		if err := p.post(r, forwarded, &upstream); err != nil {
+			p.config.OnError(fmt.Errorf("error forwarding batch: %s", err))
+
+			for _, raw := range forwarded {
+				req := &request{}
+				json.Unmarshal(raw, req)
+
+				responses = append(responses, errorResponse(req.Id, codeServerError, "upstream error"))
+			}
+		}
+
+		for _, res := range upstream {
+			responses = append(responses, res)
+		}
+	}
+
+	writeJSON(w, responses)
+}
+
+// send a transaction where the rules route it
+func (p *Proxy) handle(ctx context.Context, req *request) *response {
+	raw, err := rawTransaction(req)
+
+	if err != nil {
+		return errorResponse(req.Id, codeInvalidParams, err.Error())
+	}
+
+	tx := new(types.Transaction)
+
+	if err := tx.UnmarshalBinary(raw); err != nil {
+		return errorResponse(req.Id, codeInvalidParams, fmt.Sprintf("invalid transaction: %s", err))
+	}
+
+	from, err := types.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+
+	if err != nil {
+		return errorResponse(req.Id, codeInvalidParams, fmt.Sprintf("invalid sender: %s", err))
+	}
+
+	route := p.route(req.Method, from, tx.To())
+
+	switch route {
+	case RouteInject:
+		hash, err := p.sdk.Transactions().Inject(ctx, p.chainId(tx), tx)
+
+		if err != nil {
+			return p.fromError(req.Id, err)
+		}
+
+		return &response{Jsonrpc: "2.0", Id: req.Id, Result: hash}
+	default:
+		_, err := p.sdk.Pool().Send(ctx, &merkle.NewTransactionOptions{
+			Transaction:    tx,
+			FeeRecipient:   p.config.FeeRecipient,
+			Source:         p.config.Source,
+			PreventRevert:  p.config.PreventRevert,
+			Hints:          p.config.Hints,
+			PrivacyProfile: p.config.PrivacyProfile,
+			BundleTypes:    p.config.BundleTypes,
+			ReleaseTargets: p.config.ReleaseTargets,
+		})
+
+		if err != nil {
+			return p.fromError(req.Id, err)
+		}
+
+		return &response{Jsonrpc: "2.0", Id: req.Id, Result: tx.Hash()}
+	}
+}
+
+// the route of a transaction, private transactions always go to the pool
+func (p *Proxy) route(method string, from common.Address, to *common.Address) Route {
+	if method == "eth_sendPrivateTransaction" {
+		return RoutePool
+	}
+
+	for _, rule := range p.config.Rules {
+		if rule.matches(from, to) {
+			return rule.Route
+		}
+	}
+
+	return p.config.DefaultRoute
+}
+
+func (p *Proxy) chainId(tx *types.Transaction) merkle.MerkleChainId {
+	if tx.ChainId().Sign() == 0 {
+		return p.config.ChainId
+	}
+
+	return merkle.MerkleChainId(tx.ChainId().Int64())
+}
+
+// the signed transaction of eth_sendRawTransaction ["0x..."]
+// or eth_sendPrivateTransaction [{"tx": "0x..."}]
+func rawTransaction(req *request) ([]byte, error) {
+	if len(req.Params) == 0 {
+		return nil, fmt.Errorf("missing transaction")
+	}
+
+	var encoded hexutil.Bytes
+
+	if req.Method == "eth_sendPrivateTransaction" {
+		params := struct {
+			Tx hexutil.Bytes `json:"tx"`
+		}{}
+
+		if err := json.Unmarshal(req.Params[0], &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %s", err)
+		}
+
+		encoded = params.Tx
+	} else if err := json.Unmarshal(req.Params[0], &encoded); err != nil {
+		return nil, fmt.Errorf("invalid params: %s", err)
+	}
+
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("missing transaction")
+	}
+
+	return encoded, nil
+}
+
+// node errors and rejection reasons are passed to the client, other errors
+// are reported with OnError and replaced by a generic message
+func (p *Proxy) fromError(id json.RawMessage, err error) *response {
+	rpcErr := &merkle.RPCError{}
+
+	if errors.As(err, &rpcErr) {
+		return &response{Jsonrpc: "2.0", Id: id, Error: &rpcError{Code: rpcErr.Code, Message: rpcErr.Message, Data: rpcErr.Data}}
+	}
+
+	rejectedErr := &merkle.SubmissionRejectedError{}
+
+	if errors.As(err, &rejectedErr) {
+		return errorResponse(id, codeServerError, fmt.Sprintf("transaction rejected: %s", rejectedErr.Reason))
+	}
+
+	p.config.OnError(err)
+
+	return errorResponse(id, codeServerError, "internal error")
+}
+
+// forward a request as is and copy the upstream response back
+func (p *Proxy) forward(w http.ResponseWriter, r *http.Request, body []byte) {
+	res, err := p.do(r, body)
+
+	if err != nil {
+		p.config.OnError(fmt.Errorf("error forwarding request: %s", err))
+		http.Error(w, "upstream error", http.StatusBadGateway)
+		return
+	}
+
+	defer res.Body.Close()
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+
+	w.WriteHeader(res.StatusCode)
+	io.Copy(w, res.Body)
+}
+
+// post a body to the upstream node and decode the response
+func (p *Proxy) post(r *http.Request, body interface{}, resp interface{}) error {
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling body: %s", err)
+	}
+
+	res, err := p.do(r, bodyBytes)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("upstream returned %s", res.Status)
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(resp); err != nil {
+		return fmt.Errorf("error decoding upstream response: %s", err)
+	}
+
+	return nil
+}
+
+func (p *Proxy) do(r *http.Request, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(r.Context(), "POST", p.config.Upstream, bytes.NewReader(body))
+
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %s", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// some nodes authenticate with a header
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+
+	res, err := p.config.Client.Do(req)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reaching upstream: %s", err)
+	}
+
+	return res, nil
+}
+
+func writeJSON(w http.ResponseWriter, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
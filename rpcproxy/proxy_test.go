@@ -0,0 +1,353 @@
+package rpcproxy
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+var router = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+
+// a node answering every request of a batch with its method, and recording
+// the methods it received
+type testUpstream struct {
+	mu      sync.Mutex
+	methods []string
+}
+
+func (u *testUpstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	batch := []request{}
+
+	if err := json.Unmarshal(body, &batch); err != nil {
+		req := request{}
+		json.Unmarshal(body, &req)
+
+		u.record(req.Method)
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":%q}`, req.Id, req.Method)
+		return
+	}
+
+	responses := []string{}
+
+	for _, req := range batch {
+		u.record(req.Method)
+		responses = append(responses, fmt.Sprintf(`{"jsonrpc":"2.0","id":%s,"result":%q}`, req.Id, req.Method))
+	}
+
+	fmt.Fprintf(w, "[%s]", strings.Join(responses, ","))
+}
+
+func (u *testUpstream) record(method string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.methods = append(u.methods, method)
+}
+
+func (u *testUpstream) received() []string {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	return append([]string{}, u.methods...)
+}
+
+// the merkle apis, injections and pool submissions are answered by their
+// handlers and the paths of the requests are recorded
+type testMerkle struct {
+	mu    sync.Mutex
+	paths []string
+
+	inject http.HandlerFunc
+	pool   http.HandlerFunc
+}
+
+func (m *testMerkle) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	m.paths = append(m.paths, r.URL.Path)
+	m.mu.Unlock()
+
+	if strings.HasPrefix(r.URL.Path, "/rpc/") {
+		m.inject(w, r)
+		return
+	}
+
+	m.pool(w, r)
+}
+
+func (m *testMerkle) requested() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]string{}, m.paths...)
+}
+
+func injected(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintf(w, `{"jsonrpc":"2.0","id":1,"result":"%s"}`, common.HexToHash("0x01"))
+}
+
+func submitted(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprint(w, `{"id":"submission"}`)
+}
+
+// a proxy in front of a test upstream and test merkle apis, both closed with the test
+func testProxy(t *testing.T, fake *testMerkle, config Config) (*Proxy, *testUpstream) {
+	t.Helper()
+
+	if fake.inject == nil {
+		fake.inject = injected
+	}
+
+	if fake.pool == nil {
+		fake.pool = submitted
+	}
+
+	testutil.Serve(t, fake)
+
+	upstream := &testUpstream{}
+	server := httptest.NewServer(upstream)
+	t.Cleanup(server.Close)
+
+	sdk := merkle.New()
+	sdk.SetApiKey("test")
+
+	config.Upstream = server.URL
+
+	// its own transport, the node isn't served by the merkle handler
+	config.Client = server.Client()
+
+	proxy, err := New(sdk, config)
+
+	if err != nil {
+		t.Fatalf("failed to create proxy: %s", err)
+	}
+
+	return proxy, upstream
+}
+
+// post a body to the proxy, returns the response body
+func call(t *testing.T, proxy *Proxy, body string) string {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	proxy.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("got status %d: %s", w.Code, w.Body.String())
+	}
+
+	return w.Body.String()
+}
+
+func sendRawTransaction(t *testing.T, key *ecdsa.PrivateKey, id int) string {
+	t.Helper()
+
+	raw, _ := testutil.SignedTransaction(t, key, uint64(id), &router).MarshalBinary()
+
+	return fmt.Sprintf(`{"jsonrpc":"2.0","id":%d,"method":"eth_sendRawTransaction","params":["0x%x"]}`, id, raw)
+}
+
+func testKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+
+	if err != nil {
+		t.Fatalf("failed to generate key: %s", err)
+	}
+
+	return key
+}
+
+func TestForwardsOtherMethods(t *testing.T) {
+	fake := &testMerkle{}
+	proxy, upstream := testProxy(t, fake, Config{})
+
+	res := call(t, proxy, `{"jsonrpc":"2.0","id":7,"method":"eth_blockNumber","params":[]}`)
+
+	if res != `{"jsonrpc":"2.0","id":7,"result":"eth_blockNumber"}` {
+		t.Fatalf("got response %s", res)
+	}
+
+	if got := upstream.received(); len(got) != 1 || got[0] != "eth_blockNumber" {
+		t.Fatalf("upstream received %v", got)
+	}
+
+	if got := fake.requested(); len(got) != 0 {
+		t.Fatalf("merkle received %v", got)
+	}
+}
+
+func TestRoutesTransactions(t *testing.T) {
+	key := testKey(t)
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	raw, _ := testutil.SignedTransaction(t, key, 0, &router).MarshalBinary()
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		rules  []Rule
+		want   string
+	}{
+		{"default route", "eth_sendRawTransaction", fmt.Sprintf(`["0x%x"]`, raw), nil, "/transactions"},
+		{"sender rule", "eth_sendRawTransaction", fmt.Sprintf(`["0x%x"]`, raw), []Rule{{Senders: []common.Address{sender}, Route: RouteInject}}, "/rpc/test/1"},
+		{"destination rule", "eth_sendRawTransaction", fmt.Sprintf(`["0x%x"]`, raw), []Rule{{Destinations: []common.Address{router}, Route: RouteInject}}, "/rpc/test/1"},
+		{"other destination", "eth_sendRawTransaction", fmt.Sprintf(`["0x%x"]`, raw), []Rule{{Destinations: []common.Address{{}}, Route: RouteInject}}, "/transactions"},
+		{"private transaction", "eth_sendPrivateTransaction", fmt.Sprintf(`[{"tx":"0x%x"}]`, raw), []Rule{{Senders: []common.Address{sender}, Route: RouteInject}}, "/transactions"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &testMerkle{}
+			proxy, upstream := testProxy(t, fake, Config{Rules: test.rules})
+
+			res := response{}
+			body := call(t, proxy, fmt.Sprintf(`{"jsonrpc":"2.0","id":1,"method":%q,"params":%s}`, test.method, test.params))
+
+			if err := json.Unmarshal([]byte(body), &res); err != nil || res.Error != nil {
+				t.Fatalf("got response %s", body)
+			}
+
+			if got := fake.requested(); len(got) != 1 || got[0] != test.want {
+				t.Fatalf("merkle received %v, want %s", got, test.want)
+			}
+
+			if got := upstream.received(); len(got) != 0 {
+				t.Fatalf("upstream received %v", got)
+			}
+		})
+	}
+}
+
+func TestBatchForwardsOtherMethods(t *testing.T) {
+	key := testKey(t)
+
+	fake := &testMerkle{}
+	proxy, upstream := testProxy(t, fake, Config{})
+
+	body := call(t, proxy, fmt.Sprintf(`[
+		{"jsonrpc":"2.0","id":1,"method":"eth_blockNumber","params":[]},
+		%s,
+		{"jsonrpc":"2.0","id":3,"method":"eth_chainId","params":[]}
+	]`, sendRawTransaction(t, key, 2)))
+
+	responses := []response{}
+
+	if err := json.Unmarshal([]byte(body), &responses); err != nil {
+		t.Fatalf("got response %s", body)
+	}
+
+	// a response per request, whatever the order
+	ids := map[string]bool{}
+
+	for _, res := range responses {
+		if res.Error != nil {
+			t.Fatalf("got error %s for request %s", res.Error.Message, res.Id)
+		}
+
+		ids[string(res.Id)] = true
+	}
+
+	if len(responses) != 3 || !ids["1"] || !ids["2"] || !ids["3"] {
+		t.Fatalf("got response %s", body)
+	}
+
+	// the transaction isn't forwarded
+	if got := upstream.received(); strings.Join(got, ",") != "eth_blockNumber,eth_chainId" {
+		t.Fatalf("upstream received %v", got)
+	}
+
+	if got := fake.requested(); len(got) != 1 || got[0] != "/transactions" {
+		t.Fatalf("merkle received %v", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	key := testKey(t)
+
+	tests := []struct {
+		name     string
+		route    Route
+		fake     *testMerkle
+		code     int
+		message  string
+		reported bool
+	}{
+		{
+			name:  "node error",
+			route: RouteInject,
+			fake: &testMerkle{inject: func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32003,"message":"nonce too low"}}`)
+			}},
+			code:    -32003,
+			message: "nonce too low",
+		},
+		{
+			name:  "rejected",
+			route: RoutePool,
+			fake: &testMerkle{pool: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				fmt.Fprint(w, `{"error":"unknown hint"}`)
+			}},
+			code:    codeServerError,
+			message: "transaction rejected: unknown hint",
+		},
+		{
+			name:  "pool failure",
+			route: RoutePool,
+			fake: &testMerkle{pool: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+				fmt.Fprint(w, "database unavailable")
+			}},
+			code:     codeServerError,
+			message:  "internal error",
+			reported: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reported := []error{}
+
+			proxy, _ := testProxy(t, test.fake, Config{
+				DefaultRoute: test.route,
+				OnError: func(err error) {
+					reported = append(reported, err)
+				},
+			})
+
+			res := response{}
+			body := call(t, proxy, sendRawTransaction(t, key, 1))
+
+			if err := json.Unmarshal([]byte(body), &res); err != nil || res.Error == nil {
+				t.Fatalf("got response %s", body)
+			}
+
+			if res.Error.Code != test.code || res.Error.Message != test.message {
+				t.Fatalf("got error %d %q, want %d %q", res.Error.Code, res.Error.Message, test.code, test.message)
+			}
+
+			// the hidden error is reported, the others reach the client
+			if len(reported) > 0 != test.reported {
+				t.Fatalf("reported %v", reported)
+			}
+
+			if test.reported && strings.Contains(res.Error.Message, "database") {
+				t.Fatalf("the error reached the client: %s", res.Error.Message)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// each migration moves the schema one version up, the schema
+// version is the number of migrations applied, never edit or
+// reorder a released migration, append a new one instead
+var migrations = []string{
+	// 1: initial schema
+	`
+	CREATE TABLE transactions (
+		hash      TEXT PRIMARY KEY,
+		chain_id  INTEGER NOT NULL,
+		type      INTEGER NOT NULL,
+		sender    TEXT NOT NULL,
+		recipient TEXT,
+		nonce     INTEGER NOT NULL,
+		value     TEXT NOT NULL,
+		gas       INTEGER NOT NULL,
+		raw       BLOB NOT NULL,
+		seen_at   INTEGER NOT NULL
+	);
+
+	CREATE INDEX transactions_sender_seen_at ON transactions (sender, seen_at);
+
+	CREATE TABLE auctions (
+		id            TEXT PRIMARY KEY,
+		chain_id      INTEGER NOT NULL,
+		fee_recipient TEXT NOT NULL,
+		created_at    INTEGER NOT NULL,
+		closes_at     INTEGER NOT NULL,
+		tx_hash       TEXT NOT NULL,
+		tx_from       TEXT NOT NULL,
+		tx_to         TEXT NOT NULL,
+		tx_value      TEXT,
+		tx_data       BLOB,
+		tx_gas        INTEGER NOT NULL
+	);
+
+	CREATE INDEX auctions_fee_recipient ON auctions (fee_recipient, created_at);
+
+	CREATE TABLE traces (
+		id            INTEGER PRIMARY KEY AUTOINCREMENT,
+		hash          TEXT NOT NULL,
+		chain_id      INTEGER NOT NULL,
+		first_seen_at INTEGER NOT NULL,
+		tx_data       TEXT,
+		recorded_at   INTEGER NOT NULL
+	);
+
+	CREATE INDEX traces_hash ON traces (hash);
+
+	CREATE TABLE trace_observations (
+		trace_id INTEGER NOT NULL REFERENCES traces (id) ON DELETE CASCADE,
+		time     INTEGER NOT NULL,
+		origin   TEXT NOT NULL
+	);
+
+	CREATE INDEX trace_observations_trace_id ON trace_observations (trace_id);
+
+	CREATE TABLE simulations (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		chain_id     INTEGER NOT NULL,
+		block_number TEXT,
+		payload      TEXT NOT NULL,
+		recorded_at  INTEGER NOT NULL
+	);
+
+	CREATE TABLE bids (
+		id         TEXT PRIMARY KEY,
+		auction_id TEXT,
+		tx_hash    TEXT NOT NULL,
+		txs        TEXT NOT NULL,
+		sent_at    INTEGER NOT NULL
+	);
+
+	CREATE INDEX bids_auction_id ON bids (auction_id);
+	CREATE INDEX bids_tx_hash ON bids (tx_hash);
+	`,
+
+	// 2: the revealed fields of auctions, hidden fields are stored empty.
+	// Auctions saved before stored hidden fields as zero values, so which
+	// were revealed is unknown and only the hash is kept
+	`
+	ALTER TABLE auctions ADD COLUMN tx_hints TEXT;
+	ALTER TABLE auctions ADD COLUMN tx_selector BLOB;
+	ALTER TABLE auctions ADD COLUMN tx_logs TEXT;
+
+	UPDATE auctions SET tx_hints = 'hash' WHERE tx_hints IS NULL;
+	`,
+}
+
+// the schema version of the database
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var version int
+
+	err := db.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version)
+
+	if err != nil {
+		return 0, fmt.Errorf("error reading schema version: %s", err)
+	}
+
+	return version, nil
+}
+
+// apply the migrations the database is missing, each in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	version, err := schemaVersion(ctx, db)
+
+	if err != nil {
+		return err
+	}
+
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this sdk (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.BeginTx(ctx, nil)
+
+		if err != nil {
+			return fmt.Errorf("error starting migration %d: %s", i+1, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error applying migration %d: %s", i+1, err)
+		}
+
+		// pragmas can't be bound, the version is always a number
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("error updating schema version: %s", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("error committing migration %d: %s", i+1, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,610 @@
+// Package storage keeps a local history of merkle objects in a SQLite database.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+
+	// pure go sqlite driver, no cgo required
+	_ "modernc.org/sqlite"
+)
+
+type Store struct {
+	db *sql.DB
+}
+
+// open (or create) the database at path and migrate it to the latest schema
+func Open(ctx context.Context, path string) (*Store, error) {
+	// the path is escaped, sqlite decodes it and a ? or # would end it
+	escaped := (&url.URL{Path: path}).EscapedPath()
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=foreign_keys(1)&_pragma=journal_mode(WAL)&_pragma=busy_timeout(5000)", escaped))
+
+	if err != nil {
+		return nil, fmt.Errorf("error opening database: %s", err)
+	}
+
+	// sqlite only supports one writer at a time
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Store{
+		db: db,
+	}, nil
+}
+
+// the schema version of the database
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	return schemaVersion(ctx, s.db)
+}
+
+// the underlying database, for custom queries
+func (s *Store) DB() *sql.DB {
+	return s.db
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// a transaction seen on the stream
+type StoredTransaction struct {
+	Transaction *types.Transaction
+	From        common.Address
+	SeenAt      time.Time
+}
+
+// a bid sent for an auction
+type Bid struct {
+	// the bid id returned by the relay
+	Id string
+
+	// the auction the bid was for, empty if unknown
+	AuctionId string
+
+	// the hash of the backrun transaction
+	Hash common.Hash
+
+	// the hex encoded transactions of the bid
+	Txs []string
+
+	SentAt time.Time
+}
+
+// a simulation result with the time it was recorded
+type StoredSimulation struct {
+	Id         int64
+	Result     *merkle.SimulationResult
+	RecordedAt time.Time
+}
+
+// save a streamed transaction, saving the same hash twice keeps the first one
+func (s *Store) SaveTransaction(ctx context.Context, tx *types.Transaction, seenAt time.Time) error {
+	from, err := types.LatestSignerForChainID(tx.ChainId()).Sender(tx)
+
+	if err != nil {
+		return fmt.Errorf("error getting transaction sender: %s", err)
+	}
+
+	raw, err := tx.MarshalBinary()
+
+	if err != nil {
+		return fmt.Errorf("error marshalling transaction: %s", err)
+	}
+
+	var to *string
+
+	if tx.To() != nil {
+		addr := tx.To().String()
+		to = &addr
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO transactions (hash, chain_id, type, sender, recipient, nonce, value, gas, raw, seen_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (hash) DO NOTHING`,
+		tx.Hash().String(),
+		tx.ChainId().Int64(),
+		int(tx.Type()),
+		from.String(),
+		to,
+		int64(tx.Nonce()),
+		tx.Value().String(),
+		int64(tx.Gas()),
+		raw,
+		seenAt.UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error saving transaction: %s", err)
+	}
+
+	return nil
+}
+
+// transactions sent by an address and seen in [from, to)
+func (s *Store) TransactionsBySender(ctx context.Context, sender common.Address, from time.Time, to time.Time) ([]*StoredTransaction, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT sender, raw, seen_at FROM transactions
+		WHERE sender = ? AND seen_at >= ? AND seen_at < ?
+		ORDER BY seen_at`,
+		sender.String(),
+		from.UnixNano(),
+		to.UnixNano(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying transactions: %s", err)
+	}
+
+	defer rows.Close()
+
+	txs := []*StoredTransaction{}
+
+	for rows.Next() {
+		var senderHex string
+		var raw []byte
+		var seenAt int64
+
+		if err := rows.Scan(&senderHex, &raw, &seenAt); err != nil {
+			return nil, fmt.Errorf("error reading transaction: %s", err)
+		}
+
+		tx := new(types.Transaction)
+
+		if err := tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("error decoding transaction: %s", err)
+		}
+
+		txs = append(txs, &StoredTransaction{
+			Transaction: tx,
+			From:        common.HexToAddress(senderHex),
+			SeenAt:      time.Unix(0, seenAt),
+		})
+	}
+
+	return txs, rows.Err()
+}
+
+// save an auction, saving the same auction twice keeps the first one
+func (s *Store) SaveAuction(ctx context.Context, auction *merkle.Auction) error {
+	tx := auction.Transaction
+
+	if tx == nil {
+		return fmt.Errorf("auction %s has no transaction", auction.Id)
+	}
+
+	// hidden fields are stored empty, the hints tell them apart
+	var from, to string
+	var value, logs *string
+	var gas int64
+
+	if tx.From != nil {
+		from = tx.From.String()
+	}
+
+	if tx.To != nil {
+		to = tx.To.String()
+	}
+
+	if tx.Value != nil {
+		v := tx.Value.String()
+		value = &v
+	}
+
+	if tx.Gas != nil {
+		gas = int64(*tx.Gas)
+	}
+
+	if tx.Logs != nil {
+		encoded, err := json.Marshal(tx.Logs)
+
+		if err != nil {
+			return fmt.Errorf("error encoding auction logs: %s", err)
+		}
+
+		l := string(encoded)
+		logs = &l
+	}
+
+	hints := []string{}
+
+	for _, hint := range tx.Hints {
+		hints = append(hints, string(hint))
+	}
+
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO auctions (id, chain_id, fee_recipient, created_at, closes_at, tx_hash, tx_from, tx_to, tx_value, tx_data, tx_gas, tx_hints, tx_selector, tx_logs)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		auction.Id,
+		auction.ChainId,
+		common.HexToAddress(auction.FeeRecipient).String(),
+		auction.CreatedAt.UnixNano(),
+		auction.ClosesAt.UnixNano(),
+		tx.Hash.String(),
+		from,
+		to,
+		value,
+		tx.Data,
+		gas,
+		strings.Join(hints, ","),
+		tx.FunctionSelector,
+		logs,
+	)
+
+	if err != nil {
+		return fmt.Errorf("error saving auction: %s", err)
+	}
+
+	return nil
+}
+
+// auctions paying a fee recipient, newest first
+func (s *Store) AuctionsByFeeRecipient(ctx context.Context, feeRecipient common.Address) ([]*merkle.Auction, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, chain_id, fee_recipient, created_at, closes_at, tx_hash, tx_from, tx_to, tx_value, tx_data, tx_gas, tx_hints, tx_selector, tx_logs
+		FROM auctions WHERE fee_recipient = ?
+		ORDER BY created_at DESC`,
+		feeRecipient.String(),
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying auctions: %s", err)
+	}
+
+	defer rows.Close()
+
+	auctions := []*merkle.Auction{}
+
+	for rows.Next() {
+		var auction merkle.Auction
+		var createdAt, closesAt, gas int64
+		var hash, from, to string
+		var value, hints, logs sql.NullString
+		var data, selector []byte
+
+		err := rows.Scan(&auction.Id, &auction.ChainId, &auction.FeeRecipient, &createdAt, &closesAt, &hash, &from, &to, &value, &data, &gas, &hints, &selector, &logs)
+
+		if err != nil {
+			return nil, fmt.Errorf("error reading auction: %s", err)
+		}
+
+		auction.CreatedAt = time.Unix(0, createdAt)
+		auction.ClosesAt = time.Unix(0, closesAt)
+
+		tx := &merkle.AuctionTransaction{
+			Hash: common.HexToHash(hash),
+		}
+
+		for _, hint := range strings.Split(hints.String, ",") {
+			if hint != "" {
+				tx.Hints = append(tx.Hints, merkle.Hint(hint))
+			}
+		}
+
+		if tx.Reveals(merkle.HintFrom) {
+			a := common.HexToAddress(from)
+			tx.From = &a
+		}
+
+		if tx.Reveals(merkle.HintTo) && to != "" {
+			a := common.HexToAddress(to)
+			tx.To = &a
+		}
+
+		if tx.Reveals(merkle.HintValue) && value.Valid {
+			tx.Value, _ = new(big.Int).SetString(value.String, 10)
+		}
+
+		if tx.Reveals(merkle.HintGas) {
+			g := uint64(gas)
+			tx.Gas = &g
+		}
+
+		if tx.Reveals(merkle.HintCalldata) {
+			tx.Data = data
+
+			if tx.Data == nil {
+				tx.Data = []byte{}
+			}
+		}
+
+		if tx.Reveals(merkle.HintFunctionSelector) {
+			tx.FunctionSelector = selector
+
+			if len(tx.Data) >= 4 {
+				tx.FunctionSelector = tx.Data[:4]
+			}
+		}
+
+		if logs.Valid {
+			if err := json.Unmarshal([]byte(logs.String), &tx.Logs); err != nil {
+				return nil, fmt.Errorf("error decoding auction logs: %s", err)
+			}
+		}
+
+		auction.Transaction = tx
+		auctions = append(auctions, &auction)
+	}
+
+	return auctions, rows.Err()
+}
+
+// save a trace observation, every call records a new snapshot of the trace
+func (s *Store) SaveTrace(ctx context.Context, trace *merkle.MerkleTrace) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %s", err)
+	}
+
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(
+		ctx,
+		`INSERT INTO traces (hash, chain_id, first_seen_at, tx_data, recorded_at) VALUES (?, ?, ?, ?, ?)`,
+		trace.Hash,
+		int64(trace.ChainId),
+		trace.FirstSeenAt.UnixNano(),
+		trace.TxData,
+		time.Now().UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error saving trace: %s", err)
+	}
+
+	traceId, err := res.LastInsertId()
+
+	if err != nil {
+		return fmt.Errorf("error saving trace: %s", err)
+	}
+
+	for _, observation := range trace.Trace {
+		_, err := tx.ExecContext(
+			ctx,
+			`INSERT INTO trace_observations (trace_id, time, origin) VALUES (?, ?, ?)`,
+			traceId,
+			observation.Time.UnixNano(),
+			observation.Origin,
+		)
+
+		if err != nil {
+			return fmt.Errorf("error saving observation: %s", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error saving trace: %s", err)
+	}
+
+	return nil
+}
+
+// every recorded trace of a hash, oldest first
+func (s *Store) Traces(ctx context.Context, hash string) ([]*merkle.MerkleTrace, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, hash, chain_id, first_seen_at, tx_data FROM traces WHERE hash = ? ORDER BY recorded_at`,
+		hash,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying traces: %s", err)
+	}
+
+	ids := []int64{}
+	traces := []*merkle.MerkleTrace{}
+
+	for rows.Next() {
+		var id, chainId, firstSeenAt int64
+		var txData sql.NullString
+		var trace merkle.MerkleTrace
+
+		if err := rows.Scan(&id, &trace.Hash, &chainId, &firstSeenAt, &txData); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("error reading trace: %s", err)
+		}
+
+		trace.ChainId = merkle.MerkleChainId(chainId)
+		trace.FirstSeenAt = time.Unix(0, firstSeenAt)
+		trace.TxData = txData.String
+		trace.Trace = []merkle.Observation{}
+
+		ids = append(ids, id)
+		traces = append(traces, &trace)
+	}
+
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading traces: %s", err)
+	}
+
+	// one connection only, so the observations are read after the traces
+	for i, id := range ids {
+		observations, err := s.observations(ctx, id)
+
+		if err != nil {
+			return nil, err
+		}
+
+		traces[i].Trace = observations
+	}
+
+	return traces, nil
+}
+
+func (s *Store) observations(ctx context.Context, traceId int64) ([]merkle.Observation, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT time, origin FROM trace_observations WHERE trace_id = ? ORDER BY time`, traceId)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying observations: %s", err)
+	}
+
+	defer rows.Close()
+
+	observations := []merkle.Observation{}
+
+	for rows.Next() {
+		var at int64
+		var origin string
+
+		if err := rows.Scan(&at, &origin); err != nil {
+			return nil, fmt.Errorf("error reading observation: %s", err)
+		}
+
+		observations = append(observations, merkle.Observation{
+			Time:   time.Unix(0, at),
+			Origin: origin,
+		})
+	}
+
+	return observations, rows.Err()
+}
+
+// save a simulation result, returns its id
+func (s *Store) SaveSimulation(ctx context.Context, result *merkle.SimulationResult) (int64, error) {
+	payload, err := json.Marshal(result)
+
+	if err != nil {
+		return 0, fmt.Errorf("error marshalling simulation: %s", err)
+	}
+
+	var blockNumber *string
+
+	if result.BlockNumber != nil {
+		b := result.BlockNumber.String()
+		blockNumber = &b
+	}
+
+	res, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO simulations (chain_id, block_number, payload, recorded_at) VALUES (?, ?, ?, ?)`,
+		result.ChainId,
+		blockNumber,
+		string(payload),
+		time.Now().UnixNano(),
+	)
+
+	if err != nil {
+		return 0, fmt.Errorf("error saving simulation: %s", err)
+	}
+
+	return res.LastInsertId()
+}
+
+// a saved simulation by id
+func (s *Store) Simulation(ctx context.Context, id int64) (*StoredSimulation, error) {
+	var payload string
+	var recordedAt int64
+
+	err := s.db.QueryRowContext(ctx, `SELECT payload, recorded_at FROM simulations WHERE id = ?`, id).Scan(&payload, &recordedAt)
+
+	if err != nil {
+		return nil, fmt.Errorf("error reading simulation: %s", err)
+	}
+
+	var result merkle.SimulationResult
+
+	if err := json.Unmarshal([]byte(payload), &result); err != nil {
+		return nil, fmt.Errorf("error decoding simulation: %s", err)
+	}
+
+	return &StoredSimulation{
+		Id:         id,
+		Result:     &result,
+		RecordedAt: time.Unix(0, recordedAt),
+	}, nil
+}
+
+// save one of our bids
+func (s *Store) SaveBid(ctx context.Context, bid *Bid) error {
+	txs, err := json.Marshal(bid.Txs)
+
+	if err != nil {
+		return fmt.Errorf("error marshalling bid: %s", err)
+	}
+
+	var auctionId *string
+
+	if bid.AuctionId != "" {
+		auctionId = &bid.AuctionId
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO bids (id, auction_id, tx_hash, txs, sent_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO NOTHING`,
+		bid.Id,
+		auctionId,
+		bid.Hash.String(),
+		string(txs),
+		bid.SentAt.UnixNano(),
+	)
+
+	if err != nil {
+		return fmt.Errorf("error saving bid: %s", err)
+	}
+
+	return nil
+}
+
+// our bids for an auction, oldest first
+func (s *Store) BidsByAuction(ctx context.Context, auctionId string) ([]*Bid, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, auction_id, tx_hash, txs, sent_at FROM bids WHERE auction_id = ? ORDER BY sent_at`,
+		auctionId,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("error querying bids: %s", err)
+	}
+
+	defer rows.Close()
+
+	bids := []*Bid{}
+
+	for rows.Next() {
+		var bid Bid
+		var auction sql.NullString
+		var hash, txs string
+		var sentAt int64
+
+		if err := rows.Scan(&bid.Id, &auction, &hash, &txs, &sentAt); err != nil {
+			return nil, fmt.Errorf("error reading bid: %s", err)
+		}
+
+		if err := json.Unmarshal([]byte(txs), &bid.Txs); err != nil {
+			return nil, fmt.Errorf("error decoding bid: %s", err)
+		}
+
+		bid.AuctionId = auction.String
+		bid.Hash = common.HexToHash(hash)
+		bid.SentAt = time.Unix(0, sentAt)
+
+		bids = append(bids, &bid)
+	}
+
+	return bids, rows.Err()
+}
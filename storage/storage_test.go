@@ -0,0 +1,323 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/merkle3/merkle-sdk-go/internal/testutil"
+	"github.com/merkle3/merkle-sdk-go/merkle"
+)
+
+var (
+	router       = common.HexToAddress("0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+	feeRecipient = common.HexToAddress("0xb4e16d0168e52d35cacd2c6185b44281ec28c9dc")
+)
+
+// a store in a temporary directory, closed with the test
+func testStore(t *testing.T) *Store {
+	t.Helper()
+
+	store, err := Open(context.Background(), filepath.Join(t.TempDir(), "merkle.db"))
+
+	if err != nil {
+		t.Fatalf("failed to open: %s", err)
+	}
+
+	t.Cleanup(func() { store.Close() })
+
+	return store
+}
+
+func TestTransactionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := testStore(t)
+
+	key, _ := crypto.GenerateKey()
+	sender := crypto.PubkeyToAddress(key.PublicKey)
+	seenAt := time.Unix(1700000000, 5)
+
+	tx := testutil.SignedTransaction(t, key, 3, &router)
+
+	// the first one is kept
+	for i := 0; i < 2; i++ {
+		if err := store.SaveTransaction(ctx, tx, seenAt.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("failed to save: %s", err)
+		}
+	}
+
+	txs, err := store.TransactionsBySender(ctx, sender, seenAt, seenAt.Add(time.Minute))
+
+	if err != nil {
+		t.Fatalf("failed to load: %s", err)
+	}
+
+	if len(txs) != 1 || txs[0].Transaction.Hash() != tx.Hash() || txs[0].From != sender || !txs[0].SeenAt.Equal(seenAt) {
+		t.Fatalf("got transactions %+v", txs)
+	}
+
+	// the range is half open
+	if txs, _ := store.TransactionsBySender(ctx, sender, seenAt.Add(-time.Minute), seenAt); len(txs) != 0 {
+		t.Fatalf("got %d transactions before they were seen", len(txs))
+	}
+}
+
+func TestAuctionRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := testStore(t)
+
+	gas := uint64(21000)
+	created := time.Unix(1700000000, 0)
+
+	revealed := &merkle.Auction{
+		Id:           "revealed",
+		FeeRecipient: feeRecipient.String(),
+		ChainId:      1,
+		CreatedAt:    created,
+		ClosesAt:     created.Add(time.Second),
+		Transaction: &merkle.AuctionTransaction{
+			Hash:             common.HexToHash("0x01"),
+			From:             &router,
+			To:               &router,
+			Value:            big.NewInt(5),
+			Gas:              &gas,
+			Data:             []byte{1, 2, 3, 4, 5},
+			FunctionSelector: []byte{1, 2, 3, 4},
+			Logs:             []*merkle.AuctionLog{{Address: router, Topics: []common.Hash{common.HexToHash("0x02")}, Data: []byte{6}}},
+			Hints:            []merkle.Hint{merkle.HintHash, merkle.HintFrom, merkle.HintTo, merkle.HintValue, merkle.HintGas, merkle.HintCalldata, merkle.HintLogs},
+		},
+	}
+
+	hidden := &merkle.Auction{
+		Id:           "hidden",
+		FeeRecipient: feeRecipient.String(),
+		ChainId:      1,
+		CreatedAt:    created.Add(time.Minute),
+		ClosesAt:     created.Add(time.Minute + time.Second),
+		Transaction: &merkle.AuctionTransaction{
+			Hash:             common.HexToHash("0x03"),
+			FunctionSelector: []byte{1, 2, 3, 4},
+			Hints:            []merkle.Hint{merkle.HintHash, merkle.HintFunctionSelector},
+		},
+	}
+
+	for _, auction := range []*merkle.Auction{revealed, hidden, revealed} {
+		if err := store.SaveAuction(ctx, auction); err != nil {
+			t.Fatalf("failed to save: %s", err)
+		}
+	}
+
+	auctions, err := store.AuctionsByFeeRecipient(ctx, feeRecipient)
+
+	if err != nil {
+		t.Fatalf("failed to load: %s", err)
+	}
+
+	if len(auctions) != 2 {
+		t.Fatalf("got %d auctions, want 2", len(auctions))
+	}
+
+	// newest first
+	for i, want := range []*merkle.Auction{hidden, revealed} {
+		got := auctions[i]
+
+		if got.Id != want.Id || !got.CreatedAt.Equal(want.CreatedAt) || !got.ClosesAt.Equal(want.ClosesAt) {
+			t.Fatalf("got auction %s created at %s, want %s created at %s", got.Id, got.CreatedAt, want.Id, want.CreatedAt)
+		}
+
+		if !reflect.DeepEqual(got.Transaction, want.Transaction) {
+			t.Errorf("auction %s: got transaction %+v, want %+v", want.Id, got.Transaction, want.Transaction)
+		}
+	}
+}
+
+func TestTraceRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := testStore(t)
+
+	first := time.Unix(1700000000, 0)
+
+	trace := &merkle.MerkleTrace{
+		Hash:        "0x01",
+		ChainId:     merkle.EthereumMainnet,
+		FirstSeenAt: first,
+		TxData:      "0x02",
+		Trace: []merkle.Observation{
+			{Time: first, Origin: "a"},
+			{Time: first.Add(time.Millisecond), Origin: "b"},
+		},
+	}
+
+	// every save is a new snapshot
+	for i := 0; i < 2; i++ {
+		if err := store.SaveTrace(ctx, trace); err != nil {
+			t.Fatalf("failed to save: %s", err)
+		}
+	}
+
+	traces, err := store.Traces(ctx, "0x01")
+
+	if err != nil {
+		t.Fatalf("failed to load: %s", err)
+	}
+
+	if len(traces) != 2 {
+		t.Fatalf("got %d traces, want 2", len(traces))
+	}
+
+	for _, got := range traces {
+		if got.ChainId != trace.ChainId || !got.FirstSeenAt.Equal(first) || got.TxData != trace.TxData || len(got.Trace) != 2 {
+			t.Fatalf("got trace %+v", got)
+		}
+
+		for i, observation := range got.Trace {
+			if observation.Origin != trace.Trace[i].Origin || !observation.Time.Equal(trace.Trace[i].Time) {
+				t.Fatalf("got observation %+v, want %+v", observation, trace.Trace[i])
+			}
+		}
+	}
+}
+
+func TestSimulationAndBidRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	store := testStore(t)
+
+	result := &merkle.SimulationResult{ChainId: 1, ProcessTime: 12}
+
+	id, err := store.SaveSimulation(ctx, result)
+
+	if err != nil {
+		t.Fatalf("failed to save simulation: %s", err)
+	}
+
+	simulation, err := store.Simulation(ctx, id)
+
+	if err != nil {
+		t.Fatalf("failed to load simulation: %s", err)
+	}
+
+	if simulation.Id != id || !reflect.DeepEqual(simulation.Result, result) {
+		t.Fatalf("got simulation %+v", simulation)
+	}
+
+	bid := &Bid{Id: "bid", AuctionId: "auction", Hash: common.HexToHash("0x01"), Txs: []string{"02", "03"}, SentAt: time.Unix(1700000000, 0)}
+
+	if err := store.SaveBid(ctx, bid); err != nil {
+		t.Fatalf("failed to save bid: %s", err)
+	}
+
+	bids, err := store.BidsByAuction(ctx, "auction")
+
+	if err != nil {
+		t.Fatalf("failed to load bids: %s", err)
+	}
+
+	if len(bids) != 1 || !bids[0].SentAt.Equal(bid.SentAt) {
+		t.Fatalf("got bids %+v", bids)
+	}
+
+	bids[0].SentAt = bid.SentAt
+
+	if !reflect.DeepEqual(bids[0], bid) {
+		t.Fatalf("got bid %+v, want %+v", bids[0], bid)
+	}
+}
+
+func TestMigrateFromFirstVersion(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "merkle.db")
+
+	// a database written by the first schema, hidden fields were zero values
+	db, err := sql.Open("sqlite", path)
+
+	if err != nil {
+		t.Fatalf("failed to open: %s", err)
+	}
+
+	_, err = db.ExecContext(ctx, migrations[0]+`
+		PRAGMA user_version = 1;
+		INSERT INTO auctions (id, chain_id, fee_recipient, created_at, closes_at, tx_hash, tx_from, tx_to, tx_value, tx_data, tx_gas)
+		VALUES ('old', 1, '`+feeRecipient.String()+`', 0, 0, '`+common.HexToHash("0x01").String()+`', '`+(common.Address{}).String()+`', '`+router.String()+`', NULL, NULL, 0);
+	`)
+
+	if err != nil {
+		t.Fatalf("failed to write the first schema: %s", err)
+	}
+
+	db.Close()
+
+	store, err := Open(ctx, path)
+
+	if err != nil {
+		t.Fatalf("failed to migrate: %s", err)
+	}
+
+	defer store.Close()
+
+	if version, err := store.SchemaVersion(ctx); err != nil || version != len(migrations) {
+		t.Fatalf("got schema version %d (%v), want %d", version, err, len(migrations))
+	}
+
+	auctions, err := store.AuctionsByFeeRecipient(ctx, feeRecipient)
+
+	if err != nil || len(auctions) != 1 {
+		t.Fatalf("got auctions %v (%v)", auctions, err)
+	}
+
+	// which fields were revealed is unknown, none is trusted
+	tx := auctions[0].Transaction
+
+	if !reflect.DeepEqual(tx.Hints, []merkle.Hint{merkle.HintHash}) {
+		t.Fatalf("got hints %v", tx.Hints)
+	}
+
+	if tx.From != nil || tx.To != nil || tx.Value != nil || tx.Gas != nil || tx.Data != nil {
+		t.Fatalf("got hidden fields %+v", tx)
+	}
+}
+
+func TestNewerSchemaIsRefused(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "merkle.db")
+
+	db, err := sql.Open("sqlite", path)
+
+	if err != nil {
+		t.Fatalf("failed to open: %s", err)
+	}
+
+	if _, err := db.ExecContext(ctx, "PRAGMA user_version = 1000"); err != nil {
+		t.Fatalf("failed to set the version: %s", err)
+	}
+
+	db.Close()
+
+	if store, err := Open(ctx, path); err == nil {
+		store.Close()
+		t.Fatalf("a newer schema should be refused")
+	}
+}
+
+func TestOpenEscapesPath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a?b#c %41.db")
+
+	store, err := Open(context.Background(), path)
+
+	if err != nil {
+		t.Fatalf("failed to open: %s", err)
+	}
+
+	store.Close()
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("the database isn't at its path: %s", err)
+	}
+}